@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/i18n"
 )
 
 // Define a custom type for the context key to avoid potential collisions
@@ -33,3 +34,22 @@ func (a *application) contextGetUser(r *http.Request) *data.User {
 
 	return user
 }
+
+// requestLanguage picks the language for responses: the Accept-Language
+// header when it names a language we support, then the signed-in user's
+// stored locale, then English. Errors can happen before authentication, so
+// the user is optional here
+func (a *application) requestLanguage(r *http.Request) string {
+	if lang := i18n.Negotiate(r.Header.Get("Accept-Language")); lang != "" {
+		return lang
+	}
+
+	user, ok := r.Context().Value(userContextKey).(*data.User)
+	if ok && !user.IsAnonymous() {
+		if lang, ok := i18n.Supported(user.Locale); ok {
+			return lang
+		}
+	}
+
+	return i18n.Default
+}
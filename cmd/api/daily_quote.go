@@ -0,0 +1,86 @@
+// Filename: cmd/api/daily_quote.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+// GET /v1/quotes/daily
+// The user's quote for today in their time zone, the same on every device.
+// It can be cached until the user's midnight, and If-None-Match gets a 304
+// while the quote hasn't changed
+func (app *application) dailyQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	loc := user.Location()
+	today := localToday(loc)
+
+	quote, err := app.quoteModel.Daily(user.ID, today, app.config.quotes.dailyRepeatDays)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	now := time.Now().In(loc)
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+
+	etag, err := dailyQuoteETag(quote, today)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(midnight.Sub(now).Seconds())))
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Authorization")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	responseData := envelope{
+		"quote": quote,
+		"date":  today.Format(data.DateLayout),
+	}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// dailyQuoteETag changes with the day and with anything in the quote's
+// response, such as an edit, new likes or a renamed author
+func dailyQuoteETag(quote *data.Quote, day time.Time) (string, error) {
+	js, err := json.Marshal(quote)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(append([]byte(day.Format(data.DateLayout)+"|"), js...))
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:12]) + `"`, nil
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
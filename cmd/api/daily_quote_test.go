@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestDailyQuoteETag(t *testing.T) {
+	quote := data.Quote{ID: 3, Username: "alice", Content: "Keep going", Likes: 2, Author: "Anon", Tags: []string{"focus"}}
+	day := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	etag := mustDailyQuoteETag(t, quote, day)
+
+	if mustDailyQuoteETag(t, quote, day) != etag {
+		t.Fatal("expected the same ETag for the same quote and day")
+	}
+	if mustDailyQuoteETag(t, quote, day.AddDate(0, 0, 1)) == etag {
+		t.Error("expected a new ETag the next day")
+	}
+
+	changes := map[string]func(q *data.Quote){
+		"edited":        func(q *data.Quote) { q.Content = "Keep going!" },
+		"liked":         func(q *data.Quote) { q.Likes++ },
+		"new author":    func(q *data.Quote) { q.Author = "Seneca" },
+		"new source":    func(q *data.Quote) { q.Source = "Letters" },
+		"retagged":      func(q *data.Quote) { q.Tags = []string{"focus", "exams"} },
+		"owner renamed": func(q *data.Quote) { q.Username = "alice2" },
+	}
+	for name, change := range changes {
+		changed := quote
+		change(&changed)
+		if mustDailyQuoteETag(t, changed, day) == etag {
+			t.Errorf("expected a new ETag when the quote is %s", name)
+		}
+	}
+}
+
+func mustDailyQuoteETag(t *testing.T, quote data.Quote, day time.Time) string {
+	t.Helper()
+	etag, err := dailyQuoteETag(&quote, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return etag
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`*`, true},
+		{`"xyz"`, false},
+		{``, false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %t; want %t", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestParamOrFixed(t *testing.T) {
+	app := &application{}
+	served := ""
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { served = name }
+	}
+
+	router := httprouter.New()
+	router.HandlerFunc(http.MethodGet, "/v1/quotes/:id", app.paramOrFixed("id", handler("byID"), map[string]http.HandlerFunc{
+		"daily": handler("daily"),
+	}))
+
+	tests := map[string]string{
+		"/v1/quotes/daily": "daily",
+		"/v1/quotes/12":    "byID",
+		"/v1/quotes/dail":  "byID",
+	}
+	for path, want := range tests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if served != want {
+			t.Errorf("GET %s served by %q; want %q", path, served, want)
+		}
+	}
+}
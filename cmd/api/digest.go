@@ -0,0 +1,131 @@
+// Filename: cmd/api/digest.go
+package main
+
+import (
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+// sendWeeklyDigests emails last week's summary to every user for whom it is
+// Monday morning. Each digest is claimed before it is sent so it goes out
+// once, and released again if it couldn't be queued
+func (app *application) sendWeeklyDigests(done <-chan struct{}) {
+	recipients, err := app.digestModel.DueDigests(app.config.reminders.digestHour, reminderBatchSize)
+	if err != nil {
+		app.logger.Error("finding weekly digests", "error", err.Error())
+		return
+	}
+
+	sent := 0
+	for _, recipient := range recipients {
+		if stopping(done) {
+			return
+		}
+
+		claimed, err := app.digestModel.Claim(recipient)
+		if err != nil {
+			app.logger.Error("claiming weekly digest", "user_id", recipient.UserID, "error", err.Error())
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		digest, err := app.weeklyDigest(recipient)
+		if err == nil {
+			err = app.notifier.Notify(Notification{
+				UserID:   recipient.UserID,
+				Email:    recipient.Email,
+				Template: "weekly_digest.tmpl",
+				Locale:   recipient.Locale,
+				Category: data.CategoryDigests,
+				Data:     digest,
+			})
+		}
+		if err != nil {
+			app.logger.Error("sending weekly digest", "user_id", recipient.UserID, "error", err.Error())
+			if err := app.digestModel.Release(recipient); err != nil {
+				app.logger.Error("releasing weekly digest", "user_id", recipient.UserID, "error", err.Error())
+			}
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		app.logger.Info("sent weekly digests", "count", sent)
+	}
+}
+
+// weeklyDigest gathers the template data for one digest: the week's study
+// minutes per subject, completed sessions, goals completed during the week
+// and due in the next seven days, and the current streak
+func (app *application) weeklyDigest(recipient *data.DigestRecipient) (map[string]any, error) {
+	weekStart, err := time.Parse(data.DateLayout, recipient.WeekStart)
+	if err != nil {
+		return nil, err
+	}
+	today, err := time.Parse(data.DateLayout, recipient.Today)
+	if err != nil {
+		return nil, err
+	}
+	weekEnd := weekStart.AddDate(0, 0, 6)
+
+	stats, err := app.statsModel.StudyForUser(recipient.UserID, weekStart, weekEnd, recipient.TimeZone, recipient.FirstDayOfWeek)
+	if err != nil {
+		return nil, err
+	}
+
+	streaks, err := app.statsModel.StreaksForUser(recipient.UserID, today, recipient.TimeZone, app.config.stats.streakMinMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := app.digestModel.CompletedGoals(recipient.UserID, recipient.WeekStart, weekEnd.Format(data.DateLayout), recipient.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	dueSoon, err := app.digestModel.DueGoals(recipient.UserID, recipient.Today, today.AddDate(0, 0, 6).Format(data.DateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	// subjects that were only planned have nothing to report
+	subjects := []map[string]any{}
+	for _, s := range stats.Subjects {
+		if s.FocusedMinutes > 0 || s.CompletedSessions > 0 {
+			subjects = append(subjects, map[string]any{
+				"subject":  s.Subject,
+				"minutes":  s.FocusedMinutes,
+				"sessions": s.CompletedSessions,
+			})
+		}
+	}
+
+	return map[string]any{
+		"username":          recipient.Username,
+		"weekStart":         recipient.WeekStart,
+		"weekEnd":           weekEnd.Format(data.DateLayout),
+		"focusedMinutes":    stats.Totals.FocusedMinutes,
+		"completedSessions": stats.Totals.CompletedSessions,
+		"subjects":          subjects,
+		"goalsCompleted":    digestGoals(completed),
+		"goalsDueSoon":      digestGoals(dueSoon),
+		"currentStreak":     streaks.CurrentStreak,
+	}, nil
+}
+
+// digestGoals turns goals into template data
+func digestGoals(goals []*data.DigestGoal) []map[string]any {
+	items := []map[string]any{}
+	for _, goal := range goals {
+		items = append(items, map[string]any{
+			"goalID":     goal.ID,
+			"goalText":   goal.GoalText,
+			"targetDate": goal.TargetDate,
+		})
+	}
+	return items
+}
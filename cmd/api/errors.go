@@ -2,8 +2,10 @@
 package main
 
 import (
-	"fmt"
 	"net/http"
+
+	"github.com/aiycoleman/Study-Mate/internal/i18n"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
 )
 
 // log an error message
@@ -13,6 +15,13 @@ func (a *application) logError(r *http.Request, err error) {
 	a.logger.Error(err.Error(), "method", method, "uri", uri)
 }
 
+// send an error response with the catalog message for id, in the language
+// of the request
+func (a *application) localizedErrorResponse(w http.ResponseWriter, r *http.Request, status int, id string, args ...any) {
+	message := i18n.T(a.requestLanguage(r), id, args...)
+	a.errorResponseJSON(w, r, status, message)
+}
+
 // send an error response in JSON
 func (a *application) errorResponseJSON(w http.ResponseWriter, r *http.Request, status int, message any) {
 	errorData := envelope{"error": message}
@@ -28,25 +37,21 @@ func (a *application) serverErrorResponse(w http.ResponseWriter, r *http.Request
 	// 1st log the error message
 	a.logError(r, err)
 	// prepare message to response to send to the client
-	message := "the server encountered a problem and could not process your request"
-	a.errorResponseJSON(w, r, http.StatusInternalServerError, message)
+	a.localizedErrorResponse(w, r, http.StatusInternalServerError, "server_error")
 }
 
 // send an error response if our client messes up with a 404
 func (a *application) notFoundResponse(w http.ResponseWriter, r *http.Request) {
 	// Only log server errors, not client errors
 	// Prepare a response to send to the client
-	message := "the requested resource could not be found"
-	a.errorResponseJSON(w, r, http.StatusNotFound, message)
+	a.localizedErrorResponse(w, r, http.StatusNotFound, "not_found")
 }
 
 // send an error response if our client messes up a 405
 func (a *application) methodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
 	// we don't log, since its a client error
 	// Prepare a formatted response to send to the client
-	message := fmt.Sprintf("the %s method is not supported for this resource", r.Method)
-
-	a.errorResponseJSON(w, r, http.StatusMethodNotAllowed, message)
+	a.localizedErrorResponse(w, r, http.StatusMethodNotAllowed, "method_not_allowed", r.Method)
 }
 
 // Sending an error response if client messes up with 400(bad request)
@@ -54,27 +59,25 @@ func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Reques
 	app.errorResponseJSON(w, r, http.StatusBadRequest, err.Error())
 }
 
-// How to responds to validation errors in HTTP requests
-func (a *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
-	a.errorResponseJSON(w, r, http.StatusUnprocessableEntity, errors)
+// How to responds to validation errors in HTTP requests. Every field gets
+// its error code and a message in the language of the request
+func (a *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, v *validator.Validator) {
+	a.errorResponseJSON(w, r, http.StatusUnprocessableEntity, v.Localize(a.requestLanguage(r)))
 }
 
 // Send and error response if rate limit exceeded(429 - too many requests)
 func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
-	message := "rate limit exceeded"
-	app.errorResponseJSON(w, r, http.StatusTooManyRequests, message)
+	app.localizedErrorResponse(w, r, http.StatusTooManyRequests, "rate_limit_exceeded")
 }
 
 // send an error response if we have an edit conflict status 409
 func (app *application) editConflictResponse(w http.ResponseWriter, r *http.Request) {
-	message := "unable to update the record due to an edit conflict, please try again"
-	app.errorResponseJSON(w, r, http.StatusConflict, message)
+	app.localizedErrorResponse(w, r, http.StatusConflict, "edit_conflict")
 }
 
 // Return a 401 status code
 func (a *application) invalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
-	message := "invalid authentication credentials"
-	a.errorResponseJSON(w, r, http.StatusUnauthorized, message)
+	a.localizedErrorResponse(w, r, http.StatusUnauthorized, "invalid_credentials")
 }
 
 // We set the WWW-Authenticate header to give a hint to the user as
@@ -83,20 +86,16 @@ func (a *application) invalidAuthenticationTokenResponse(w http.ResponseWriter,
 
 	w.Header().Set("WWW-Authenticate", "Bearer")
 
-	message := "invalid or missing authentication token"
-	a.errorResponseJSON(w, r, http.StatusUnauthorized, message)
+	a.localizedErrorResponse(w, r, http.StatusUnauthorized, "invalid_auth_token")
 
 }
 
 // 403 Forbidden status if bad permission
 func (a *application) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
-	message := "your user account doesn't have the necessary permissions to access this resource"
-
-	a.errorResponseJSON(w, r, http.StatusForbidden, message)
+	a.localizedErrorResponse(w, r, http.StatusForbidden, "not_permitted")
 }
 
 // duplicateRoleResponse returns a 409 Conflict if a user already has that role.
 func (a *application) duplicateRoleResponse(w http.ResponseWriter, r *http.Request, roleName string) {
-	message := fmt.Sprintf("User has already been assigned the '%s' role", roleName)
-	a.errorResponseJSON(w, r, http.StatusConflict, message)
+	a.localizedErrorResponse(w, r, http.StatusConflict, "duplicate_role", roleName)
 }
@@ -0,0 +1,307 @@
+// Filename: cmd/api/events.go
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+	"github.com/lib/pq"
+)
+
+// How many events a stream reads from the database at a time
+const eventBatchSize = 100
+
+// How far back a stream looks for events that committed after ones with
+// higher IDs. It only has to outlast the transactions that record events
+const lateEventWindow = 30 * time.Second
+
+// eventBroker wakes up the open streams of a user when they have new
+// events. The events themselves are read from the database, so a wake-up
+// carries no data and several can be merged into one
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan struct{}]struct{}
+	closing     chan struct{}
+	closeOnce   sync.Once
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: map[int64]map[chan struct{}]struct{}{},
+		closing:     make(chan struct{}),
+	}
+}
+
+// subscribe returns a channel that receives a value when the user has new
+// events, and a function to call once the stream ends
+func (b *eventBroker) subscribe(userID int64) (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = map[chan struct{}]struct{}{}
+	}
+	b.subscribers[userID][wake] = struct{}{}
+
+	return wake, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[userID], wake)
+		if len(b.subscribers[userID]) == 0 {
+			delete(b.subscribers, userID)
+		}
+	}
+}
+
+// publish wakes up the user's streams
+func (b *eventBroker) publish(userID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for wake := range b.subscribers[userID] {
+		wakeUp(wake)
+	}
+}
+
+// publishAll wakes up every stream, for when notifications may have been
+// missed
+func (b *eventBroker) publishAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, streams := range b.subscribers {
+		for wake := range streams {
+			wakeUp(wake)
+		}
+	}
+}
+
+// wakeUp doesn't block: a stream that already has a wake-up waiting will
+// read everything new anyway
+func wakeUp(wake chan struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// close ends every stream, so a graceful shutdown doesn't wait for them
+func (b *eventBroker) close() {
+	b.closeOnce.Do(func() { close(b.closing) })
+}
+
+// startEvents listens for the events NOTIFYs from Postgres and hands them to
+// the broker, and prunes old events. Listening on Postgres rather than
+// publishing in process means a change made through any API instance, or
+// by a background job, reaches streams on every instance
+func (app *application) startEvents(done <-chan struct{}) {
+	listener := pq.NewListener(app.config.db.dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			app.logger.Error("events listener", "error", err.Error())
+		}
+	})
+	err := listener.Listen(data.EventsChannel)
+	if err != nil {
+		app.logger.Error("listening for events", "error", err.Error())
+	}
+
+	app.background(func() {
+		defer listener.Close()
+
+		for {
+			select {
+			case <-done:
+				return
+			case n := <-listener.Notify:
+				// nil after a reconnect, when anything may have been missed
+				if n == nil {
+					app.events.publishAll()
+					continue
+				}
+				userID, ok := parseEventNotification(n.Extra)
+				if !ok {
+					app.logger.Warn("unexpected events notification", "payload", n.Extra)
+					continue
+				}
+				app.events.publish(userID)
+			case <-time.After(90 * time.Second):
+				// notice a dead connection even when nothing happens
+				go listener.Ping()
+			}
+		}
+	})
+
+	app.schedule(done, time.Hour, app.pruneEvents)
+}
+
+// parseEventNotification gets the user ID out of "<user id>:<event id>"
+func parseEventNotification(payload string) (int64, bool) {
+	idStr, _, found := strings.Cut(payload, ":")
+	if !found {
+		return 0, false
+	}
+	userID, err := strconv.ParseInt(idStr, 10, 64)
+	return userID, err == nil
+}
+
+// pruneEvents deletes the events too old to resume from
+func (app *application) pruneEvents(done <-chan struct{}) {
+	deleted, err := app.eventModel.Prune(app.config.events.retention)
+	if err != nil {
+		app.logger.Error("pruning events", "error", err.Error())
+		return
+	}
+	if deleted > 0 {
+		app.logger.Info("pruned events", "count", deleted)
+	}
+}
+
+// GET /v1/events
+// A Server-Sent Events stream of changes to the user's sessions, goals and
+// notifications. A client that reconnects with Last-Event-ID (or the
+// last_event_id query parameter) first gets the events it missed, and may
+// get again the ones from just before it, so it should skip IDs it has
+// already seen. A comment line is sent every heartbeat interval to keep
+// proxies from closing the connection
+func (app *application) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	var lastID int64
+	if lastEventID != "" {
+		var err error
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			v := validator.New()
+			v.AddError("last_event_id", "integer")
+			app.failedValidationResponse(w, r, v)
+			return
+		}
+	}
+
+	// subscribe before reading, so nothing recorded in between is missed
+	wake, unsubscribe := app.events.subscribe(user.ID)
+	defer unsubscribe()
+
+	cursor := &eventCursor{lastID: lastID, sent: map[int64]time.Time{}}
+	if lastEventID == "" {
+		var err error
+		cursor.lastID, err = app.eventModel.LatestID(user.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		recent, err := app.eventModel.RecentIDs(user.ID, cursor.lastID, lateEventWindow)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		for _, id := range recent {
+			cursor.sent[id] = time.Now()
+		}
+	}
+
+	// the server's write timeout is for ordinary requests
+	rc := http.NewResponseController(w)
+	err := rc.SetWriteDeadline(time.Time{})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(app.config.events.heartbeat)
+	defer heartbeat.Stop()
+
+	// replay what was missed, then wait for more
+	send := true
+	for {
+		if send {
+			err = app.writeEvents(w, user.ID, cursor)
+		} else {
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			// usually the client went away
+			app.logger.Debug("event stream ended", "user_id", user.ID, "error", err.Error())
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-app.events.closing:
+			return
+		case <-wake:
+			send = true
+		case <-heartbeat.C:
+			send = false
+		}
+	}
+}
+
+// eventCursor is how far a stream has got: the highest event ID it sent,
+// and when it sent the ones that may still be within the late event window
+type eventCursor struct {
+	lastID int64
+	sent   map[int64]time.Time
+}
+
+// skip lists the recently sent IDs, forgetting the ones that are well past
+// the late event window
+func (c *eventCursor) skip(now time.Time) []int64 {
+	ids := make([]int64, 0, len(c.sent))
+	for id, at := range c.sent {
+		if now.Sub(at) > 2*lateEventWindow {
+			delete(c.sent, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// writeEvents writes the user's events that the cursor hasn't sent yet,
+// including late ones with IDs below the last sent, and moves it on
+func (app *application) writeEvents(w http.ResponseWriter, userID int64, cursor *eventCursor) error {
+	for {
+		events, err := app.eventModel.Since(userID, cursor.lastID, lateEventWindow, cursor.skip(time.Now()), eventBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := writeEvent(w, event); err != nil {
+				return err
+			}
+			cursor.sent[event.ID] = time.Now()
+			cursor.lastID = max(cursor.lastID, event.ID)
+		}
+
+		if len(events) < eventBatchSize {
+			return nil
+		}
+	}
+}
+
+// writeEvent writes one event in the text/event-stream format
+func writeEvent(w http.ResponseWriter, event *data.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func newTestAppEvents() *application {
+	app := newTestApp()
+	app.events = newEventBroker()
+	return app
+}
+
+func TestEventBroker_WakesOnlyTheUsersStreams(t *testing.T) {
+	b := newEventBroker()
+	alice, unsubscribeAlice := b.subscribe(1)
+	bob, unsubscribeBob := b.subscribe(2)
+	defer unsubscribeBob()
+
+	// several events before the stream reads merge into one wake-up
+	b.publish(1)
+	b.publish(1)
+
+	if len(alice) != 1 {
+		t.Fatalf("expected one wake-up for alice; got %d", len(alice))
+	}
+	if len(bob) != 0 {
+		t.Fatalf("expected no wake-up for bob; got %d", len(bob))
+	}
+
+	unsubscribeAlice()
+	if _, ok := b.subscribers[1]; ok {
+		t.Fatal("expected alice's subscription to be removed")
+	}
+
+	b.publishAll()
+	if len(bob) != 1 {
+		t.Fatalf("expected publishAll to wake bob; got %d", len(bob))
+	}
+}
+
+func TestParseEventNotification(t *testing.T) {
+	tests := []struct {
+		payload string
+		userID  int64
+		ok      bool
+	}{
+		{"42:1007", 42, true},
+		{"42", 0, false},
+		{"x:1007", 0, false},
+	}
+
+	for _, tt := range tests {
+		userID, ok := parseEventNotification(tt.payload)
+		if userID != tt.userID || ok != tt.ok {
+			t.Errorf("parseEventNotification(%q) = %d, %t; want %d, %t", tt.payload, userID, ok, tt.userID, tt.ok)
+		}
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	rr := httptest.NewRecorder()
+	event := &data.Event{ID: 7, Type: "goal.updated", Data: json.RawMessage(`{"id": 3}`)}
+
+	if err := writeEvent(rr, event); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id: 7\nevent: goal.updated\ndata: {\"id\": 3}\n\n"
+	if rr.Body.String() != want {
+		t.Errorf("expected %q; got %q", want, rr.Body.String())
+	}
+}
+
+func TestEventCursor_SkipForgetsOldEvents(t *testing.T) {
+	now := time.Now()
+	cursor := &eventCursor{lastID: 9, sent: map[int64]time.Time{
+		7: now.Add(-3 * lateEventWindow),
+		9: now.Add(-time.Second),
+	}}
+
+	skip := cursor.skip(now)
+	if len(skip) != 1 || skip[0] != 9 {
+		t.Fatalf("expected to skip only event 9; got %v", skip)
+	}
+	if _, ok := cursor.sent[7]; ok {
+		t.Fatal("expected event 7 to be forgotten")
+	}
+}
+
+func TestEventsHandler_InvalidLastEventID(t *testing.T) {
+	app := newTestAppEvents()
+	req := httptest.NewRequest(http.MethodGet, "/v1/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.eventsHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
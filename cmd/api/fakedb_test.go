@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakeResult is what the fake database answers to one statement
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+// fakeStatement is a statement the fake database was sent
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// fakeDB records every statement sent to it and answers with whatever
+// respond returns, so handlers can be tested without Postgres
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []fakeStatement
+	respond    func(query string, args []driver.Value) fakeResult
+}
+
+func newFakeDB(respond func(query string, args []driver.Value) fakeResult) (*sql.DB, *fakeDB) {
+	f := &fakeDB{respond: respond}
+	return sql.OpenDB(fakeConnector{f}), f
+}
+
+// index returns the position of the first recorded statement containing
+// fragment, or -1 when there is none
+func (f *fakeDB) index(fragment string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, s := range f.statements {
+		if strings.Contains(s.query, fragment) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *fakeDB) run(query string, args []driver.NamedValue) fakeResult {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	f.mu.Lock()
+	f.statements = append(f.statements, fakeStatement{query: query, args: values})
+	f.mu.Unlock()
+
+	return f.respond(query, values)
+}
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{c.db}, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fakedb: open through newFakeDB")
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements are not supported")
+}
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+// BeginTx accepts read-only transactions too. Statements run as they come,
+// so a rollback undoes nothing
+func (c fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := c.db.run(query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result := c.db.run(query, args)
+	if result.err != nil {
+		return nil, result.err
+	}
+	return driver.RowsAffected(len(result.rows)), nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
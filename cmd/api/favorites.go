@@ -0,0 +1,96 @@
+// Filename: cmd/api/favorites.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// POST /v1/quotes/:id/favorite
+func (app *application) favoriteQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	app.setQuoteFavorite(w, r, true)
+}
+
+// DELETE /v1/quotes/:id/favorite
+func (app *application) unfavoriteQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	app.setQuoteFavorite(w, r, false)
+}
+
+// setQuoteFavorite answers with the quote, so the app can show the new like
+// count
+func (app *application) setQuoteFavorite(w http.ResponseWriter, r *http.Request, favorite bool) {
+	user := app.contextGetUser(r)
+
+	quote, ok := app.getVisibleQuote(w, r)
+	if !ok {
+		return
+	}
+	id := quote.ID
+
+	var err error
+
+	if favorite {
+		err = app.quoteModel.Favorite(user.ID, id)
+	} else {
+		err = app.quoteModel.Unfavorite(user.ID, id)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// read it again for the new likes count. The user could already see it,
+	// as a moderator too when it isn't approved yet
+	quote, err = app.quoteModel.GetByID(id, user.ID, true)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"quote": quote, "favorited": favorite}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// GET /v1/users/me/favorites
+func (app *application) listFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	filters := data.Filters{
+		Page:         app.getSingleIntegerParameter(queryParameters, "page", 1, v),
+		PageSize:     app.getSingleIntegerParameter(queryParameters, "page_size", 15, v),
+		Sort:         app.getSingleQueryParameter(queryParameters, "sort", "-favorited_at"),
+		SortSafeList: []string{"favorited_at", "popular", "id", "-favorited_at", "-popular", "-id"},
+	}
+	data.ValidateFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	quotes, metadata, err := app.quoteModel.FavoritesForUser(user.ID, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	responseData := envelope{
+		"@metadata": metadata,
+		"quotes":    quotes,
+	}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestFavoriteQuoteHandler_InvalidID(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/quotes/0/favorite", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.favoriteQuoteHandler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
+
+func TestListFavoritesHandler_InvalidSort(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/me/favorites?sort=content", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.listFavoritesHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestFavoriteQuoteHandler_KeepsUsername(t *testing.T) {
+	db, _ := newFakeDB(func(query string, args []driver.Value) fakeResult {
+		row := []driver.Value{int64(5), int64(2), "bob", "Keep going", int64(1), "approved", "public", "", "", "", "{}", time.Now()}
+		return fakeResult{columns: make([]string, len(row)), rows: [][]driver.Value{row}}
+	})
+	defer db.Close()
+
+	app := newTestApp()
+	app.quoteModel = data.QuoteModel{DB: db}
+	req := httptest.NewRequest(http.MethodPost, "/v1/quotes/5/favorite", nil)
+	req = withIDParam(app.contextSetUser(req, &data.User{ID: 1}), "5")
+	rr := httptest.NewRecorder()
+
+	app.favoriteQuoteHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"username": "bob"`) {
+		t.Errorf("expected the quote's username in the response; body=%s", rr.Body.String())
+	}
+}
@@ -0,0 +1,176 @@
+// Filename: cmd/api/follows.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// POST /v1/follows/:id
+// Asks to follow the user. Their followers-only quotes show once they
+// approve, and they're told about the request
+func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.setFollow(w, r, true)
+}
+
+// DELETE /v1/follows/:id
+func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Request) {
+	app.setFollow(w, r, false)
+}
+
+func (app *application) setFollow(w http.ResponseWriter, r *http.Request, follow bool) {
+	user := app.contextGetUser(r)
+
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	v.Check(id != user.ID, "user_id", "follow_self")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	created := false
+	if follow {
+		created, err = app.followModel.Follow(user.ID, id)
+	} else {
+		err = app.followModel.Unfollow(user.ID, id)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if created {
+		app.notifyNewFollower(user, id)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user_id": id, "following": follow}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// notifyNewFollower tells the followee who asked to follow them. Failing to
+// is logged rather than failing the request
+func (app *application) notifyNewFollower(follower *data.User, followeeID int64) {
+	followee, err := app.userModel.GetByID(followeeID)
+	if err != nil {
+		app.logger.Error("notifying new follower", "user_id", followeeID, "error", err.Error())
+		return
+	}
+
+	err = app.notifier.Notify(Notification{
+		UserID:   followee.ID,
+		Email:    followee.Email,
+		Template: "new_follower.tmpl",
+		Locale:   followee.Locale,
+		Category: data.CategoryFollows,
+		Data: map[string]any{
+			"username":     followee.Username,
+			"followerID":   follower.ID,
+			"followerName": follower.Username,
+		},
+	})
+	if err != nil {
+		app.logger.Error("notifying new follower", "user_id", followeeID, "error", err.Error())
+	}
+}
+
+// PUT /v1/users/me/followers/:id
+// Approves the user's request to follow, which shows them the followers-only
+// quotes
+func (app *application) approveFollowerHandler(w http.ResponseWriter, r *http.Request) {
+	app.setFollower(w, r, true)
+}
+
+// DELETE /v1/users/me/followers/:id
+// Removes a follower, or turns their request down. They can ask again
+func (app *application) removeFollowerHandler(w http.ResponseWriter, r *http.Request) {
+	app.setFollower(w, r, false)
+}
+
+func (app *application) setFollower(w http.ResponseWriter, r *http.Request, approve bool) {
+	user := app.contextGetUser(r)
+
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	if approve {
+		err = app.followModel.Approve(id, user.ID)
+	} else {
+		err = app.followModel.Unfollow(id, user.ID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user_id": id, "follower": approve}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// GET /v1/users/me/followers
+func (app *application) listFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	app.listFollows(w, r, "followers", app.followModel.Followers)
+}
+
+// GET /v1/users/me/following
+func (app *application) listFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	app.listFollows(w, r, "following", app.followModel.Following)
+}
+
+func (app *application) listFollows(w http.ResponseWriter, r *http.Request, key string, list func(int64, data.Filters) ([]*data.Follow, data.Metadata, error)) {
+	user := app.contextGetUser(r)
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	filters := data.Filters{
+		Page:         app.getSingleIntegerParameter(queryParameters, "page", 1, v),
+		PageSize:     app.getSingleIntegerParameter(queryParameters, "page_size", 15, v),
+		Sort:         app.getSingleQueryParameter(queryParameters, "sort", "-followed_at"),
+		SortSafeList: []string{"followed_at", "username", "-followed_at", "-username"},
+	}
+	data.ValidateFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	follows, metadata, err := list(user.ID, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	responseData := envelope{
+		"@metadata": metadata,
+		key:         follows,
+	}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestFollowUserHandler_Self(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/follows/7", nil)
+	req = withIDParam(app.contextSetUser(req, &data.User{ID: 7}), "7")
+	rr := httptest.NewRecorder()
+
+	app.followUserHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"user_id"`) {
+		t.Fatalf("expected an error for user_id; body=%s", rr.Body.String())
+	}
+}
+
+func TestUnfollowUserHandler_InvalidID(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodDelete, "/v1/follows/abc", nil)
+	req = withIDParam(app.contextSetUser(req, &data.User{ID: 7}), "abc")
+	rr := httptest.NewRecorder()
+
+	app.unfollowUserHandler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
+
+func TestListFollowersHandler_InvalidSort(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/me/followers?sort=email", nil)
+	req = app.contextSetUser(req, &data.User{ID: 7})
+	rr := httptest.NewRecorder()
+
+	app.listFollowersHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestApproveFollowerHandler_NoRequest(t *testing.T) {
+	db, _ := newFakeDB(func(query string, args []driver.Value) fakeResult {
+		return fakeResult{}
+	})
+	defer db.Close()
+
+	app := newTestApp()
+	app.followModel = data.FollowModel{DB: db}
+	req := httptest.NewRequest(http.MethodPut, "/v1/users/me/followers/3", nil)
+	req = withIDParam(app.contextSetUser(req, &data.User{ID: 7}), "3")
+	rr := httptest.NewRecorder()
+
+	app.approveFollowerHandler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
+
+func TestRemoveFollowerHandler(t *testing.T) {
+	db, fake := newFakeDB(func(query string, args []driver.Value) fakeResult {
+		return fakeResult{}
+	})
+	defer db.Close()
+
+	app := newTestApp()
+	app.followModel = data.FollowModel{DB: db}
+	req := httptest.NewRequest(http.MethodDelete, "/v1/users/me/followers/3", nil)
+	req = withIDParam(app.contextSetUser(req, &data.User{ID: 7}), "3")
+	rr := httptest.NewRecorder()
+
+	app.removeFollowerHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	// user 3 stops following user 7, not the other way round
+	removed := fake.index("DELETE FROM follows")
+	if removed == -1 {
+		t.Fatal("expected the follow to be deleted")
+	}
+	if args := fake.statements[removed].args; args[0] != int64(3) || args[1] != int64(7) {
+		t.Errorf("deleted the follow of %v by %v; want 3 following 7", args[0], args[1])
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/aiycoleman/Study-Mate/internal/data"
@@ -19,8 +21,14 @@ func (app *application) createGoalsHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	var incomingData struct {
-		GoalText   string    `json:"goal_text"`
-		TargetDate time.Time `json:"target_date"`
+		GoalText      string    `json:"goal_text"`
+		TargetDate    time.Time `json:"target_date"`
+		TargetMinutes *int      `json:"target_minutes"`
+		Subject       string    `json:"subject"`
+		Tag           string    `json:"tag"`
+		GoalType      string    `json:"goal_type"`
+		Period        string    `json:"period"`
+		TargetCount   *int      `json:"target_count"`
 	}
 
 	err := app.readJSON(w, r, &incomingData)
@@ -30,16 +38,29 @@ func (app *application) createGoalsHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	goal := &data.Goal{
-		UserID:     user.ID,
-		GoalText:   incomingData.GoalText,
-		TargetDate: incomingData.TargetDate,
+		UserID:        user.ID,
+		GoalText:      incomingData.GoalText,
+		TargetMinutes: incomingData.TargetMinutes,
+		Subject:       strings.TrimSpace(incomingData.Subject),
+		Tag:           strings.ToLower(strings.TrimSpace(incomingData.Tag)),
+		GoalType:      incomingData.GoalType,
+		Period:        incomingData.Period,
+		TargetCount:   incomingData.TargetCount,
+	}
+	if goal.GoalType == "" {
+		goal.GoalType = data.GoalTypeOneTime
+	}
+	// target_date is a calendar date, so take the day it falls on for the user
+	if !incomingData.TargetDate.IsZero() {
+		targetDate := calendarDate(incomingData.TargetDate, user.Location())
+		goal.TargetDate = &targetDate
 	}
 
 	// Validate the goal data
 	v := validator.New()
 	data.ValidateGoal(v, goal)
 	if !v.Valid() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -50,6 +71,13 @@ func (app *application) createGoalsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// the linked sessions may already meet the target
+	goal, err = app.refreshGoalProgress(user.ID, goal.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/goals/%d", goal.ID))
 
@@ -62,23 +90,22 @@ func (app *application) createGoalsHandler(w http.ResponseWriter, r *http.Reques
 	}
 }
 
-// Display goal based on ID
+// Display goal based on ID. Like its milestones, only the owner can see it
 func (app *application) displayGoalsHandler(w http.ResponseWriter, r *http.Request) {
-	goalID, err := app.readIDParam(r)
-	if err != nil {
-		app.notFoundResponse(w, r)
+	goal := app.readOwnedGoal(w, r)
+	if goal == nil {
 		return
 	}
 
-	goal, err := app.goalModel.Get(goalID)
-	if err != nil {
-		switch {
-		case errors.Is(err, data.ErrRecordNotFound):
-			app.notFoundResponse(w, r)
-		default:
+	// ?embed=milestones includes the ordered milestones in the goal
+	var err error
+	embed := app.getMultipleQueryParameters(r.URL.Query(), "embed", []string{})
+	if slices.Contains(embed, "milestones") {
+		goal.Milestones, err = app.milestoneModel.GetAllForGoal(goal.ID)
+		if err != nil {
 			app.serverErrorResponse(w, r, err)
+			return
 		}
-		return
 	}
 
 	// Send the goal data in a JSON response
@@ -100,6 +127,7 @@ func (app *application) listGoalsHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	queryParameters := r.URL.Query()
+	v := validator.New()
 
 	// load the query parameters into the struct
 	queryParametersData.GoalText = app.getSingleQueryParameter(queryParameters, "goal_text", "")
@@ -107,8 +135,7 @@ func (app *application) listGoalsHandler(w http.ResponseWriter, r *http.Request)
 	if targetDateStr != "" {
 		targetDate, err := time.Parse("2006-01-02", targetDateStr) // or whatever format you use
 		if err != nil {
-			app.failedValidationResponse(w, r, map[string]string{"target_date": "invalid date format"})
-			return
+			v.AddError("target_date", "invalid_date")
 		}
 		queryParametersData.TargetDate = targetDate
 	}
@@ -119,12 +146,10 @@ func (app *application) listGoalsHandler(w http.ResponseWriter, r *http.Request)
 		} else if isCompletedStr == "false" {
 			queryParametersData.IsCompleted = false
 		} else {
-			app.failedValidationResponse(w, r, map[string]string{"is_completed": "must be 'true' or 'false'"})
-			return
+			v.AddError("is_completed", "boolean")
 		}
 	}
 
-	v := validator.New()
 	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
 	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 15, v)
 	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "goal_id")
@@ -132,7 +157,7 @@ func (app *application) listGoalsHandler(w http.ResponseWriter, r *http.Request)
 	// Validate the filters
 	data.ValidateFilters(v, queryParametersData.Filters)
 	if !v.Valid() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 	// Get the list of goals from the database
@@ -175,11 +200,25 @@ func (app *application) updateGoalsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// settle the closed periods before any change is applied, so they
+	// keep the old target count
+	err = app.habitModel.RecordClosedPeriods(goal, time.Now())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	// decode the incoming json data
+	// a target_minutes of 0 removes the target from the goal. The type and
+	// period of a goal are fixed once it has been created
 	var incomingData struct {
-		GoalText    *string    `json:"goal_text"`
-		TargetDate  *time.Time `json:"target_date"`
-		IsCompleted *bool      `json:"is_completed"`
+		GoalText      *string    `json:"goal_text"`
+		TargetDate    *time.Time `json:"target_date"`
+		IsCompleted   *bool      `json:"is_completed"`
+		TargetMinutes *int       `json:"target_minutes"`
+		Subject       *string    `json:"subject"`
+		Tag           *string    `json:"tag"`
+		TargetCount   *int       `json:"target_count"`
 	}
 
 	err = app.readJSON(w, r, &incomingData)
@@ -193,17 +232,34 @@ func (app *application) updateGoalsHandler(w http.ResponseWriter, r *http.Reques
 		goal.GoalText = *incomingData.GoalText
 	}
 	if incomingData.TargetDate != nil {
-		goal.TargetDate = *incomingData.TargetDate
+		user := app.contextGetUser(r)
+		targetDate := calendarDate(*incomingData.TargetDate, user.Location())
+		goal.TargetDate = &targetDate
 	}
 	if incomingData.IsCompleted != nil {
 		goal.IsCompleted = *incomingData.IsCompleted
 	}
+	if incomingData.TargetMinutes != nil {
+		goal.TargetMinutes = incomingData.TargetMinutes
+		if *incomingData.TargetMinutes == 0 {
+			goal.TargetMinutes = nil
+		}
+	}
+	if incomingData.Subject != nil {
+		goal.Subject = strings.TrimSpace(*incomingData.Subject)
+	}
+	if incomingData.Tag != nil {
+		goal.Tag = strings.ToLower(strings.TrimSpace(*incomingData.Tag))
+	}
+	if incomingData.TargetCount != nil {
+		goal.TargetCount = incomingData.TargetCount
+	}
 
 	// validate the updated goal data
 	v := validator.New()
 	data.ValidateGoal(v, goal)
 	if !v.Valid() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -214,6 +270,12 @@ func (app *application) updateGoalsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	goal, err = app.refreshGoalProgress(goal.UserID, goal.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	// send the updated goal as json response
 	data := envelope{"goal": goal}
 	err = app.writeJSON(w, http.StatusOK, data, nil)
@@ -251,3 +313,14 @@ func (app *application) deleteGoalsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 }
+
+// refreshGoalProgress completes any of the user's goals that have reached
+// their target and then reloads the goal so the response shows its progress
+func (app *application) refreshGoalProgress(userID int64, goalID int64) (*data.Goal, error) {
+	_, err := app.goalModel.CompleteReached(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.goalModel.Get(goalID)
+}
@@ -5,18 +5,11 @@ import (
     "net/http"
     "net/http/httptest"
     "testing"
-    "io"
-    "log/slog"
     "github.com/aiycoleman/Study-Mate/internal/data"
 )
 
-func newTestAppGoals() *application {
-    logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-    return &application{logger: logger}
-}
-
 func TestCreateGoalsHandler_BadJSON(t *testing.T) {
-    app := newTestAppGoals()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodPost, "/v1/goals", bytes.NewBufferString("{bad json"))
     // set an authenticated user in context to avoid panic
     usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
@@ -31,7 +24,7 @@ func TestCreateGoalsHandler_BadJSON(t *testing.T) {
 }
 
 func TestCreateGoalsHandler_InvalidData(t *testing.T) {
-    app := newTestAppGoals()
+    app := newTestApp()
     // missing goal_text should be invalid; omit target_date so it unmarshals as zero value
     payload := `{"goal_text":""}`
     req := httptest.NewRequest(http.MethodPost, "/v1/goals", bytes.NewBufferString(payload))
@@ -49,7 +42,7 @@ func TestCreateGoalsHandler_InvalidData(t *testing.T) {
 }
 
 func TestDisplayGoalsHandler_InvalidID(t *testing.T) {
-    app := newTestAppGoals()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodGet, "/v1/goals/", nil)
     rr := httptest.NewRecorder()
 
@@ -61,7 +54,7 @@ func TestDisplayGoalsHandler_InvalidID(t *testing.T) {
 }
 
 func TestUpdateGoalsHandler_InvalidID(t *testing.T) {
-    app := newTestAppGoals()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodPatch, "/v1/goals/", nil)
     rr := httptest.NewRecorder()
 
@@ -73,7 +66,7 @@ func TestUpdateGoalsHandler_InvalidID(t *testing.T) {
 }
 
 func TestDeleteGoalsHandler_InvalidID(t *testing.T) {
-    app := newTestAppGoals()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodDelete, "/v1/goals/", nil)
     rr := httptest.NewRecorder()
 
@@ -85,7 +78,7 @@ func TestDeleteGoalsHandler_InvalidID(t *testing.T) {
 }
 
 func TestListGoalsHandler_InvalidPageParam(t *testing.T) {
-    app := newTestAppGoals()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodGet, "/v1/goals?page=notint", nil)
     rr := httptest.NewRecorder()
 
@@ -94,4 +87,27 @@ func TestListGoalsHandler_InvalidPageParam(t *testing.T) {
     if rr.Code != http.StatusUnprocessableEntity {
         t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
     }
-}
\ No newline at end of file
+}
+
+func TestCreateGoalsHandler_InvalidTarget(t *testing.T) {
+    tests := []string{
+        `{"goal_text":"20 hours of Chemistry","target_date":"2030-01-01T00:00:00Z","subject":"Chemistry"}`,
+        `{"goal_text":"20 hours of Chemistry","target_date":"2030-01-01T00:00:00Z","target_minutes":-60}`,
+        `{"goal_text":"Tagged","target_date":"2030-01-01T00:00:00Z","tag":"exam"}`,
+    }
+
+    for _, payload := range tests {
+        app := newTestApp()
+        req := httptest.NewRequest(http.MethodPost, "/v1/goals", bytes.NewBufferString(payload))
+        req.Header.Set("Content-Type", "application/json")
+        usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+        req = app.contextSetUser(req, usr)
+        rr := httptest.NewRecorder()
+
+        app.createGoalsHandler(rr, req)
+
+        if rr.Code != http.StatusUnprocessableEntity {
+            t.Fatalf("payload %s: expected status %d; got %d; body=%s", payload, http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+        }
+    }
+}
@@ -0,0 +1,135 @@
+// Filename: cmd/api/habits.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// Check in on a recurring goal. Without a date the check-in is for today in
+// the user's time zone
+func (app *application) createCheckinHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		CheckinDate *time.Time `json:"checkin_date"`
+		Note        string     `json:"note"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	goal := app.readOwnedGoal(w, r)
+	if goal == nil {
+		return
+	}
+
+	user := app.contextGetUser(r)
+	today := localToday(user.Location())
+
+	checkin := &data.Checkin{
+		GoalID:      goal.ID,
+		CheckinDate: today,
+		Note:        incomingData.Note,
+	}
+	if incomingData.CheckinDate != nil {
+		checkin.CheckinDate = calendarDate(*incomingData.CheckinDate, user.Location())
+	}
+
+	v := validator.New()
+	if goal.GoalType != data.GoalTypeRecurring {
+		v.AddError("goal_type", "not_recurring")
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+	data.ValidateCheckin(v, checkin, data.PeriodStart(today, goal.Period, user.FirstDayOfWeek), today)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	err = app.habitModel.InsertCheckin(checkin)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateCheckin):
+			v.AddError("checkin_date", "already_checked_in")
+			app.failedValidationResponse(w, r, v)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// reload the goal so the response shows the new period count
+	goal, err = app.goalModel.Get(goal.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/goals/%d/history", goal.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"checkin": checkin, "goal": goal}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// The success or failure of every closed period of a recurring goal, newest
+// first. Periods that ended since the last request are recorded first
+func (app *application) goalHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	filters := data.Filters{
+		Page:         app.getSingleIntegerParameter(queryParameters, "page", 1, v),
+		PageSize:     app.getSingleIntegerParameter(queryParameters, "page_size", 15, v),
+		Sort:         "-period_start",
+		SortSafeList: []string{"-period_start"},
+	}
+	data.ValidateFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	goal := app.readOwnedGoal(w, r)
+	if goal == nil {
+		return
+	}
+
+	if goal.GoalType != data.GoalTypeRecurring {
+		v.AddError("goal_type", "not_recurring")
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	err := app.habitModel.RecordClosedPeriods(goal, time.Now())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	history, metadata, err := app.habitModel.History(goal.ID, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	responseData := envelope{
+		"@metadata": metadata,
+		"goal":      goal,
+		"history":   history,
+	}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestCreateCheckinHandler_BadJSON(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/goals/1/checkins", bytes.NewBufferString("{bad json"))
+	usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+	req = app.contextSetUser(req, usr)
+	rr := httptest.NewRecorder()
+
+	app.createCheckinHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+	}
+}
+
+func TestGoalHistoryHandler_InvalidPageParam(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/goals/1/history?page=0", nil)
+	usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+	req = app.contextSetUser(req, usr)
+	rr := httptest.NewRecorder()
+
+	app.goalHistoryHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateGoalsHandler_InvalidRecurringGoal(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"unknown goal type", `{"goal_text":"Study","goal_type":"sometimes","target_date":"2025-12-01T00:00:00Z"}`},
+		{"missing period", `{"goal_text":"Study","goal_type":"recurring","target_count":5}`},
+		{"missing target count", `{"goal_text":"Study","goal_type":"recurring","period":"weekly"}`},
+		{"count above period length", `{"goal_text":"Study","goal_type":"recurring","period":"weekly","target_count":8}`},
+		{"minute target on recurring goal", `{"goal_text":"Study","goal_type":"recurring","period":"daily","target_count":1,"target_minutes":60}`},
+		{"period on one-time goal", `{"goal_text":"Study","period":"daily","target_date":"2025-12-01T00:00:00Z"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp()
+			req := httptest.NewRequest(http.MethodPost, "/v1/goals", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+			req = app.contextSetUser(req, usr)
+			rr := httptest.NewRecorder()
+
+			app.createGoalsHandler(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestClosedPeriods_TargetCountChange(t *testing.T) {
+	// a weekly goal made on a Monday, needing 3 active days a week
+	target := 3
+	goal := &data.Goal{
+		GoalType:    data.GoalTypeRecurring,
+		Period:      data.PeriodWeekly,
+		TargetCount: &target,
+		CreatedAt:   time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC),
+	}
+
+	// two weeks have closed when the target drops to 2, in the third week.
+	// They are settled with the goal as it was stored
+	changedAt := time.Date(2025, 3, 19, 12, 0, 0, 0, time.UTC)
+	starts, ends := data.ClosedPeriods(goal, nil, time.UTC, 1, changedAt)
+	if len(starts) != 2 || starts[0] != "2025-03-03" || ends[1] != "2025-03-16" {
+		t.Fatalf("closed periods = %v to %v; want the weeks of 2025-03-03 and 2025-03-10", starts, ends)
+	}
+	before := data.SettlePeriods(starts, ends, []int64{3, 2}, *goal.TargetCount)
+
+	newTarget := 2
+	goal.TargetCount = &newTarget
+
+	// the week the change happened in closes later with the new target
+	lastStart := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	starts, ends = data.ClosedPeriods(goal, &lastStart, time.UTC, 1, time.Date(2025, 3, 25, 12, 0, 0, 0, time.UTC))
+	if len(starts) != 1 || starts[0] != "2025-03-17" {
+		t.Fatalf("closed periods after the change = %v; want only the week of 2025-03-17", starts)
+	}
+	after := data.SettlePeriods(starts, ends, []int64{2}, *goal.TargetCount)
+
+	if before[1].TargetCount != 3 || before[1].Succeeded {
+		t.Errorf("week before the change = %+v; want target 3 and not succeeded", before[1])
+	}
+	if after[0].TargetCount != 2 || !after[0].Succeeded {
+		t.Errorf("week after the change = %+v; want target 2 and succeeded", after[0])
+	}
+}
+
+func TestUpdateGoalsHandler_SettlesBeforeTargetChange(t *testing.T) {
+	// a weekly goal needing 3 active days, made three weeks ago
+	createdAt := time.Now().AddDate(0, 0, -21)
+	goalRow := []driver.Value{
+		int64(1), int64(1), "Study", nil, false, createdAt,
+		nil, "", "", "UTC", nil, int64(0), int64(0),
+		"recurring", "weekly", int64(3), nil, int64(0), nil,
+	}
+
+	db, fake := newFakeDB(func(query string, args []driver.Value) fakeResult {
+		switch {
+		case strings.Contains(query, "MAX(r.period_start)"):
+			return fakeResult{columns: []string{"tz", "first_day_of_week", "last_start"}, rows: [][]driver.Value{{"UTC", int64(1), nil}}}
+		case strings.Contains(query, "SELECT d.day::text"):
+			return fakeResult{columns: []string{"day"}}
+		case strings.Contains(query, "SET goal_text"):
+			row := []driver.Value{int64(1), int64(1), "Study", nil, false, createdAt, nil, "", "", args[6], nil}
+			return fakeResult{columns: make([]string, len(row)), rows: [][]driver.Value{row}}
+		case strings.Contains(query, "WHERE g.goal_id = $1"):
+			return fakeResult{columns: make([]string, len(goalRow)), rows: [][]driver.Value{goalRow}}
+		}
+		return fakeResult{}
+	})
+	defer db.Close()
+
+	app := newTestApp()
+	app.goalModel = data.GoalModel{DB: db}
+	app.habitModel = data.HabitModel{DB: db}
+
+	req := httptest.NewRequest(http.MethodPatch, "/v1/goals/1", bytes.NewBufferString(`{"target_count":5}`))
+	req = withIDParam(app.contextSetUser(req, &data.User{ID: 1}), "1")
+	rr := httptest.NewRecorder()
+
+	app.updateGoalsHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	settled := fake.index("INSERT INTO goal_period_results")
+	updated := fake.index("SET goal_text")
+	if settled == -1 || updated == -1 || settled > updated {
+		t.Fatalf("settled at statement %d and updated at %d; want the closed periods settled before the update", settled, updated)
+	}
+
+	// the closed weeks keep the target they were set against
+	targets := fake.statements[settled].args[4]
+	for _, target := range strings.Split(strings.Trim(targets.(string), "{}"), ",") {
+		if target != "3" {
+			t.Errorf("closed periods were settled with targets %v; want all 3", targets)
+			break
+		}
+	}
+}
@@ -104,13 +104,18 @@ func (app *application) readJSON(w http.ResponseWriter, r *http.Request, destina
 
 // Getting the idfromt he URL
 func (app *application) readIDParam(r *http.Request) (int64, error) {
+	return app.readInt64Param(r, "id")
+}
+
+// Getting any other positive integer parameter (e.g. :milestone_id) from the URL
+func (app *application) readInt64Param(r *http.Request, name string) (int64, error) {
 	// Get the URL parameters
 	params := httprouter.ParamsFromContext(r.Context())
 
 	// Convert the id from string to int
-	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
+	id, err := strconv.ParseInt(params.ByName(name), 10, 64)
 	if err != nil || id < 1 {
-		return 0, errors.New("invalid id parameter")
+		return 0, fmt.Errorf("invalid %s parameter", name)
 	}
 	return id, nil
 }
@@ -144,7 +149,7 @@ func (app *application) getSingleIntegerParameter(queryParameters url.Values, ke
 	// try to convert to an integer
 	intValue, err := strconv.Atoi(result)
 	if err != nil {
-		v.AddError(key, "must be an integer value")
+		v.AddError(key, "integer")
 		return defaultValue
 	}
 
@@ -176,6 +181,18 @@ func parseDate(dateStr string) (date time.Time) {
 	return date
 }
 
+// calendarDate returns the date that t falls on in loc, as midnight UTC so
+// it compares cleanly with the dates from parseDate
+func calendarDate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// localToday returns the current calendar date in loc
+func localToday(loc *time.Location) time.Time {
+	return calendarDate(time.Now(), loc)
+}
+
 func (app *application) getSingleInt64Parameter(values url.Values, key string, defaultValue int64) int64 {
 	s := values.Get(key)
 	if s == "" {
@@ -0,0 +1,129 @@
+// Filename: cmd/api/inbox.go
+package main
+
+import (
+	"net/http"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// GET /v1/notifications
+// The user's in-app notifications, newest first. unread=true leaves out the
+// ones already read
+func (app *application) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	unreadOnly := false
+	switch app.getSingleQueryParameter(queryParameters, "unread", "") {
+	case "", "false":
+	case "true":
+		unreadOnly = true
+	default:
+		v.AddError("unread", "boolean")
+	}
+
+	filters := data.Filters{
+		Page:         app.getSingleIntegerParameter(queryParameters, "page", 1, v),
+		PageSize:     app.getSingleIntegerParameter(queryParameters, "page_size", 20, v),
+		Sort:         app.getSingleQueryParameter(queryParameters, "sort", "-created_at"),
+		SortSafeList: []string{"created_at", "-created_at"},
+	}
+	data.ValidateFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	notifications, metadata, err := app.notificationModel.GetAllForUser(user.ID, unreadOnly, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	responseData := envelope{
+		"@metadata":     metadata,
+		"notifications": notifications,
+	}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// GET /v1/notifications/unread-count
+func (app *application) unreadNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	count, err := app.notificationModel.UnreadCount(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"unread_count": count}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// POST /v1/notifications/read
+// Marks the notifications in {"ids": [...]} as read
+func (app *application) markNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var incomingData struct {
+		IDs []int64 `json:"ids"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidateNotificationIDs(v, incomingData.IDs)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	marked, err := app.notificationModel.MarkRead(user.ID, incomingData.IDs)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeMarkedRead(w, r, user.ID, marked)
+}
+
+// POST /v1/notifications/read-all
+func (app *application) markAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	marked, err := app.notificationModel.MarkAllRead(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeMarkedRead(w, r, user.ID, marked)
+}
+
+// writeMarkedRead answers with how many were marked and the new unread
+// count, so the app can update the bell without another request
+func (app *application) writeMarkedRead(w http.ResponseWriter, r *http.Request, userID int64, marked int64) {
+	count, err := app.notificationModel.UnreadCount(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"marked": marked, "unread_count": count}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestListNotificationsHandler_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"bad unread", "/v1/notifications?unread=yes"},
+		{"unknown sort", "/v1/notifications?sort=title"},
+		{"bad page size", "/v1/notifications?page_size=500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp()
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req = app.contextSetUser(req, &data.User{ID: 1})
+			rr := httptest.NewRecorder()
+
+			app.listNotificationsHandler(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestMarkNotificationsReadHandler_InvalidIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"bad json", `{"ids": "all"}`, http.StatusBadRequest},
+		{"no ids", `{"ids": []}`, http.StatusUnprocessableEntity},
+		{"zero id", `{"ids": [1, 0]}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp()
+			req := httptest.NewRequest(http.MethodPost, "/v1/notifications/read", bytes.NewBufferString(tt.body))
+			req = app.contextSetUser(req, &data.User{ID: 1})
+			rr := httptest.NewRecorder()
+
+			app.markNotificationsReadHandler(rr, req)
+
+			if rr.Code != tt.want {
+				t.Fatalf("expected status %d; got %d; body=%s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+type recordingChannel struct {
+	got       []Notification
+	delivered bool
+	err       error
+}
+
+func (r *recordingChannel) deliver(n Notification) (bool, error) {
+	r.got = append(r.got, n)
+	return r.delivered, r.err
+}
+
+func TestMultiNotifier_DeliversOnEveryChannel(t *testing.T) {
+	failing := &recordingChannel{err: errors.New("mail server down")}
+	working := &recordingChannel{delivered: true}
+	notifier := multiNotifier{channels: []channel{failing, working}, logger: newTestApp().logger}
+
+	// the inbox entry went out, so a retry would only duplicate it
+	err := notifier.Notify(Notification{UserID: 1, Template: "goal_due_soon.tmpl"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failing.got) != 1 || len(working.got) != 1 {
+		t.Fatalf("expected one notification on each channel; got %d and %d", len(failing.got), len(working.got))
+	}
+}
+
+func TestMultiNotifier_FailsWhenNothingWentOut(t *testing.T) {
+	failing := &recordingChannel{err: errors.New("mail server down")}
+	skipping := &recordingChannel{}
+	notifier := multiNotifier{channels: []channel{failing, skipping}, logger: newTestApp().logger}
+
+	err := notifier.Notify(Notification{UserID: 1, Template: "user_welcome.tmpl", Category: data.CategorySecurity})
+	if err == nil {
+		t.Fatal("expected the failing channel's error")
+	}
+
+	skipped := multiNotifier{channels: []channel{skipping}, logger: newTestApp().logger}
+	if err := skipped.Notify(Notification{UserID: 1, Template: "goal_due_soon.tmpl"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+// Filename: cmd/api/jobs.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// A jobHandler runs one job from its stored payload
+type jobHandler func(payload json.RawMessage) error
+
+// errPermanent wraps job errors that retrying can't fix, such as a payload
+// that doesn't decode. Those jobs go straight to dead
+var errPermanent = errors.New("permanent job failure")
+
+// typedJob decodes the payload into T before calling fn, so each handler
+// works with its own payload type
+func typedJob[T any](fn func(payload T) error) jobHandler {
+	return func(raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("%w: decoding payload: %v", errPermanent, err)
+		}
+		return fn(payload)
+	}
+}
+
+// jobHandlers maps every job kind to the handler that runs it
+func (app *application) jobHandlers() map[string]jobHandler {
+	return map[string]jobHandler{
+		data.JobSendEmail:      typedJob(app.sendEmailJob),
+		data.JobSendActivation: typedJob(app.sendActivationJob),
+	}
+}
+
+// sendEmailJob sends one email. The queue takes care of retrying it
+func (app *application) sendEmailJob(payload data.SendEmailPayload) error {
+	return app.sendEmail(payload.Recipient, payload.Template, payload.Locale, payload.Data)
+}
+
+// sendEmail renders and delivers an email from a job. A template that
+// doesn't render, say because it is missing, won't on a retry either, so
+// only delivery errors are retried
+func (app *application) sendEmail(recipient, templateFile, locale string, data any) error {
+	msg, err := app.mailer.Render(recipient, templateFile, locale, data)
+	if err != nil {
+		return fmt.Errorf("%w: rendering %s: %v", errPermanent, templateFile, err)
+	}
+	return app.mailer.Deliver(msg)
+}
+
+// sendActivationJob sends the welcome email with a new activation token,
+// which expires in 3 days. The tokens from earlier attempts are deleted, so
+// only the one in the email that went out works. Users who activated in the
+// meantime are skipped
+func (app *application) sendActivationJob(payload data.SendActivationPayload) error {
+	user, err := app.userModel.GetByID(payload.UserID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return fmt.Errorf("%w: user %d not found", errPermanent, payload.UserID)
+		}
+		return err
+	}
+	if user.Activated {
+		return nil
+	}
+
+	err = app.tokenModel.DeleteAllForUser(data.ScopeActivation, user.ID)
+	if err != nil {
+		return err
+	}
+	token, err := app.tokenModel.New(user.ID, 3*24*time.Hour, data.ScopeActivation)
+	if err != nil {
+		return err
+	}
+
+	return app.sendEmail(user.Email, "user_welcome.tmpl", payload.Locale, map[string]any{
+		"activationToken": token.Plaintext,
+		"userID":          user.ID,
+	})
+}
+
+// jobBackoff is how long to wait before the next attempt: 30 seconds after
+// the first failure, doubling each time up to an hour
+func jobBackoff(attempts int) time.Duration {
+	backoff := 30 * time.Second
+	for i := 1; i < attempts && backoff < time.Hour; i++ {
+		backoff *= 2
+	}
+	return min(backoff, time.Hour)
+}
+
+// startJobWorkers starts the configured number of job workers. They stop
+// once done is closed, after finishing the job they are running. Every
+// hour the stuck jobs are buried and old succeeded ones pruned
+func (app *application) startJobWorkers(done <-chan struct{}) {
+	handlers := app.jobHandlers()
+	app.schedule(done, time.Hour, app.pruneJobs)
+
+	for i := 0; i < app.config.jobs.workers; i++ {
+		app.background(func() {
+			for {
+				// keep going while there is work, then wait for the next poll
+				worked := app.runNextJob(handlers)
+				if worked {
+					if stopping(done) {
+						return
+					}
+					continue
+				}
+
+				select {
+				case <-done:
+					return
+				case <-time.After(app.config.jobs.pollInterval):
+				}
+			}
+		})
+	}
+}
+
+// pruneJobs marks the jobs that can't be claimed again as dead, and deletes
+// the succeeded jobs past the retention period
+func (app *application) pruneJobs(done <-chan struct{}) {
+	buried, err := app.jobModel.Bury(app.config.jobs.lease)
+	if err != nil {
+		app.logger.Error("burying jobs", "error", err.Error())
+	}
+	if buried > 0 {
+		app.logger.Error("jobs ran out of lease on their last attempt", "count", buried)
+	}
+
+	deleted, err := app.jobModel.Prune(app.config.jobs.retention)
+	if err != nil {
+		app.logger.Error("pruning jobs", "error", err.Error())
+		return
+	}
+	if deleted > 0 {
+		app.logger.Info("pruned jobs", "count", deleted)
+	}
+}
+
+// runNextJob claims and runs one job. It returns false when there was no
+// job to run
+func (app *application) runNextJob(handlers map[string]jobHandler) bool {
+	job, err := app.jobModel.Claim(app.config.jobs.lease)
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			app.logger.Error("claiming job", "error", err.Error())
+		}
+		return false
+	}
+
+	handler, ok := handlers[job.Kind]
+	if !ok {
+		err = fmt.Errorf("%w: no handler for job kind %q", errPermanent, job.Kind)
+	} else {
+		err = runJob(handler, job.Payload)
+	}
+
+	if err == nil {
+		err := app.jobModel.Complete(job)
+		switch {
+		case errors.Is(err, data.ErrLostLease):
+			app.logger.Warn("job finished after its lease ran out", "job_id", job.ID, "kind", job.Kind)
+		case err != nil:
+			app.logger.Error("completing job", "job_id", job.ID, "error", err.Error())
+		}
+		return true
+	}
+
+	retry := !errors.Is(err, errPermanent)
+	if failErr := app.jobModel.Fail(job, err, jobBackoff(job.Attempts), retry); failErr != nil {
+		if errors.Is(failErr, data.ErrLostLease) {
+			app.logger.Warn("job failed after its lease ran out", "job_id", job.ID, "kind", job.Kind, "error", err.Error())
+		} else {
+			app.logger.Error("failing job", "job_id", job.ID, "error", failErr.Error())
+		}
+		return true
+	}
+
+	if job.Status == data.JobDead {
+		app.logger.Error("job is dead", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err.Error())
+	} else {
+		app.logger.Warn("job failed, will retry", "job_id", job.ID, "kind", job.Kind, "run_at", job.RunAt, "error", err.Error())
+	}
+	return true
+}
+
+// runJob runs a handler, turning a panic into an error so the job is
+// retried instead of killing the worker
+func runJob(handler jobHandler, payload json.RawMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(payload)
+}
+
+// List the jobs with the number in each status, optionally filtered by
+// status and kind
+func (app *application) listJobsHandler(w http.ResponseWriter, r *http.Request) {
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	status := app.getSingleQueryParameter(queryParameters, "status", "")
+	kind := app.getSingleQueryParameter(queryParameters, "kind", "")
+	data.ValidateJobStatus(v, status)
+
+	filters := data.Filters{
+		Page:         app.getSingleIntegerParameter(queryParameters, "page", 1, v),
+		PageSize:     app.getSingleIntegerParameter(queryParameters, "page_size", 15, v),
+		Sort:         app.getSingleQueryParameter(queryParameters, "sort", "-id"),
+		SortSafeList: []string{"id", "run_at", "updated_at", "attempts", "-id", "-run_at", "-updated_at", "-attempts"},
+	}
+	data.ValidateFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	jobs, metadata, err := app.jobModel.GetAll(status, kind, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	counts, err := app.jobModel.Counts()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	responseData := envelope{
+		"@metadata": metadata,
+		"counts":    counts,
+		"jobs":      jobs,
+	}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Show a single job, including its last error
+func (app *application) showJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	job, err := app.jobModel.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"job": job}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
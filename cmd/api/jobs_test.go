@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/mailer"
+)
+
+func TestJobBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{20, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := jobBackoff(tt.attempts); got != tt.want {
+			t.Errorf("jobBackoff(%d) = %s; want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestTypedJob_BadPayloadIsPermanent(t *testing.T) {
+	called := false
+	handler := typedJob(func(payload data.SendEmailPayload) error {
+		called = true
+		return nil
+	})
+
+	err := handler(json.RawMessage(`{"recipient": 42}`))
+	if !errors.Is(err, errPermanent) {
+		t.Fatalf("expected a permanent error; got %v", err)
+	}
+	if called {
+		t.Fatal("expected the handler not to run with a bad payload")
+	}
+}
+
+func TestTypedJob_SendEmailKeepsIntegers(t *testing.T) {
+	var got data.SendEmailPayload
+	handler := typedJob(func(payload data.SendEmailPayload) error {
+		got = payload
+		return nil
+	})
+
+	err := handler(json.RawMessage(`{"recipient":"a@example.com","template":"x.tmpl","data":{"daysLeft":1,"ratio":0.5,"items":[{"minutes":30}]}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Data["daysLeft"] != int64(1) {
+		t.Errorf("daysLeft = %#v; want int64(1)", got.Data["daysLeft"])
+	}
+	if got.Data["ratio"] != 0.5 {
+		t.Errorf("ratio = %#v; want 0.5", got.Data["ratio"])
+	}
+	items := got.Data["items"].([]any)
+	if items[0].(map[string]any)["minutes"] != int64(30) {
+		t.Errorf("nested minutes = %#v; want int64(30)", items[0].(map[string]any)["minutes"])
+	}
+}
+
+func TestSendEmail_RenderErrorIsPermanent(t *testing.T) {
+	app := newTestApp()
+	transport := &mailer.MemoryTransport{}
+	app.mailer = mailer.New(transport, "Study Mate <no-reply@example.com>")
+
+	err := app.sendEmail("alice@example.com", "missing.tmpl", "en", nil)
+	if !errors.Is(err, errPermanent) {
+		t.Fatalf("expected a permanent error; got %v", err)
+	}
+	if len(transport.Messages()) != 0 {
+		t.Fatal("expected nothing to be delivered")
+	}
+}
+
+func TestRunJob_RecoversPanic(t *testing.T) {
+	err := runJob(func(json.RawMessage) error { panic("boom") }, nil)
+	if err == nil {
+		t.Fatal("expected an error from a panicking job")
+	}
+	if errors.Is(err, errPermanent) {
+		t.Fatal("expected a panicking job to be retried")
+	}
+}
+
+func TestListJobsHandler_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"unknown status", "/v1/jobs?status=lost"},
+		{"unknown sort", "/v1/jobs?sort=payload"},
+		{"bad page", "/v1/jobs?page=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp()
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+
+			app.listJobsHandler(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/mailer"
+)
+
+func TestNewMailTransport_SMTPNeedsCredentials(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var cfg configuration
+	cfg.mailer.transport = "smtp"
+	cfg.smtp.host = "smtp.example.com"
+	cfg.smtp.port = 587
+
+	if _, err := newMailTransport(cfg, logger); err == nil {
+		t.Fatal("expected an error for smtp without credentials")
+	}
+
+	cfg.smtp.username = "user"
+	cfg.smtp.password = "secret"
+	if _, err := newMailTransport(cfg, logger); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewMailTransport_Log(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var cfg configuration
+	cfg.mailer.transport = "log"
+
+	if _, err := newMailTransport(cfg, logger); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewMailTransport_Default(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var cfg configuration
+	cfg.env = "development"
+	transport, err := newMailTransport(cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := transport.(mailer.LogTransport); !ok {
+		t.Fatalf("expected the log transport in development; got %T", transport)
+	}
+
+	cfg.env = "production"
+	if _, err := newMailTransport(cfg, logger); err == nil {
+		t.Fatal("expected an error in production without an SMTP host")
+	}
+
+	cfg.smtp.host = "smtp.example.com"
+	cfg.smtp.username = "user"
+	cfg.smtp.password = "secret"
+	transport, err = newMailTransport(cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := transport.(mailer.LogTransport); ok {
+		t.Fatal("expected smtp once there is an SMTP host")
+	}
+}
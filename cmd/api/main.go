@@ -7,6 +7,7 @@ import (
 	"database/sql"
 	"expvar"
 	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	_ "time/tzdata" // IANA time zones even when the host has none installed
 
 	"github.com/aiycoleman/Study-Mate/internal/data"
 	"github.com/aiycoleman/Study-Mate/internal/mailer"
@@ -40,6 +42,39 @@ type configuration struct {
 	cors struct {
 		trustedOrigins []string
 	}
+	stats struct {
+		streakMinMinutes int
+	}
+	quotes struct {
+		dailyRepeatDays int // days before a user can get the same daily quote again
+		reportThreshold int // unresolved reports that send a quote back to moderation
+	}
+	jobs struct {
+		workers      int
+		pollInterval time.Duration
+		lease        time.Duration
+		retention    time.Duration // how long succeeded jobs are kept
+	}
+	reminders struct {
+		enabled         bool
+		interval        time.Duration
+		sessionInterval time.Duration
+		goalWindowDays  int
+		digestInterval  time.Duration
+		digestHour      int // local hour on Monday from which the digest goes out
+	}
+	events struct {
+		heartbeat time.Duration // how often an idle stream gets a comment line
+		retention time.Duration // how long events are kept for resuming
+	}
+	unsubscribe struct {
+		baseURL string // where the links in emails point
+		secret  string // signs the one-click unsubscribe links
+	}
+	mailer struct {
+		transport string // smtp, file or log
+		dir       string // where the file transport writes .eml files
+	}
 	smtp struct {
 		host     string
 		port     int
@@ -62,6 +97,20 @@ type application struct {
 	wg                sync.WaitGroup
 	tokenModel        data.TokenModel
 	permissionModel   data.PermissionModel
+	statsModel        data.StatsModel
+	milestoneModel    data.MilestoneModel
+	habitModel        data.HabitModel
+	reminderModel     data.ReminderModel
+	notifier          notifier
+	jobModel          data.JobModel
+	digestModel       data.DigestModel
+	followModel       data.FollowModel
+	searchModel       data.SearchModel
+
+	notificationPreferenceModel data.NotificationPreferenceModel
+	notificationModel           data.NotificationModel
+	eventModel                  data.EventModel
+	events                      *eventBroker
 }
 
 // loadConfig reads configuration from command line flags
@@ -82,13 +131,37 @@ func loadConfig() configuration {
 
 	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
 
+	flag.IntVar(&cfg.stats.streakMinMinutes, "streak-min-minutes", 1, "Minimum completed minutes for a day to count towards a streak")
+
+	flag.IntVar(&cfg.quotes.dailyRepeatDays, "daily-quote-repeat-days", 30, "Days before a user can get the same quote of the day again")
+	flag.IntVar(&cfg.quotes.reportThreshold, "quote-report-threshold", 3, "Reports that hide a quote until a moderator reviews it")
+
+	flag.IntVar(&cfg.jobs.workers, "jobs-workers", 2, "Number of background job workers")
+	flag.DurationVar(&cfg.jobs.pollInterval, "jobs-poll-interval", time.Second, "How often idle job workers look for new jobs")
+	flag.DurationVar(&cfg.jobs.lease, "jobs-lease", 5*time.Minute, "How long a running job is locked before another worker may take it over")
+	flag.DurationVar(&cfg.jobs.retention, "jobs-retention", 7*24*time.Hour, "How long succeeded jobs are kept before they are deleted")
+
+	flag.BoolVar(&cfg.reminders.enabled, "reminders-enabled", true, "Enable the goal and study session reminders and the weekly digest")
+	flag.DurationVar(&cfg.reminders.interval, "reminders-interval", 15*time.Minute, "How often to check for due goal reminders")
+	flag.DurationVar(&cfg.reminders.sessionInterval, "session-reminders-interval", time.Minute, "How often to check for due study session reminders")
+	flag.IntVar(&cfg.reminders.goalWindowDays, "goal-reminder-window-days", 3, "Remind about goals due within this many days")
+	flag.DurationVar(&cfg.reminders.digestInterval, "digest-interval", 15*time.Minute, "How often to check for due weekly digests")
+	flag.IntVar(&cfg.reminders.digestHour, "digest-hour", 7, "Local hour on Monday from which the weekly digest is sent")
+
+	flag.DurationVar(&cfg.events.heartbeat, "events-heartbeat", 15*time.Second, "How often to send a heartbeat on idle event streams")
+	flag.DurationVar(&cfg.events.retention, "events-retention", 24*time.Hour, "How long events are kept for clients resuming a stream")
+
+	flag.StringVar(&cfg.unsubscribe.baseURL, "base-url", "http://localhost:4000", "Public URL of the API, used for links in emails")
+	flag.StringVar(&cfg.unsubscribe.secret, "unsubscribe-secret", "", "Secret for signing unsubscribe links, the same on every instance (falls back to $UNSUBSCRIBE_SECRET)")
+
+	flag.StringVar(&cfg.mailer.transport, "mailer-transport", "", "How to deliver email (smtp|file|log), by default smtp when there is an SMTP host and log in development")
+	flag.StringVar(&cfg.mailer.dir, "mailer-dir", "tmp/mail", "Directory for the .eml files of the file mailer transport")
+
 	// Flags for SMTP
-	flag.StringVar(&cfg.smtp.host, "smtp-host", "sandbox.smtp.mailtrap.io", "SMTP host")
-	// We have port 25, 465, 587, 2525. If 25 doesn't work choose another
-	flag.IntVar(&cfg.smtp.port, "smtp-port", 2525, "SMTP port")
-	flag.StringVar(&cfg.smtp.username, "smtp-username", "213718fe792166", "SMTP username")
-	// Use your Password value provided by Mailtrap
-	flag.StringVar(&cfg.smtp.password, "smtp-password", "54177abe81857f", "SMTP password")
+	flag.StringVar(&cfg.smtp.host, "smtp-host", "", "SMTP host (falls back to $SMTP_HOST)")
+	flag.IntVar(&cfg.smtp.port, "smtp-port", 587, "SMTP port")
+	flag.StringVar(&cfg.smtp.username, "smtp-username", "", "SMTP username (falls back to $SMTP_USERNAME)")
+	flag.StringVar(&cfg.smtp.password, "smtp-password", "", "SMTP password (falls back to $SMTP_PASSWORD)")
 
 	flag.StringVar(&cfg.smtp.sender, "smtp-sender", "Training Datatbase <no-reply@trainingdatabase.nationalinservice.net>", "SMTP sender")
 
@@ -105,6 +178,21 @@ func loadConfig() configuration {
 
 	flag.Parse()
 
+	// Read the credentials from the environment so they stay out of the
+	// process list and the -help output
+	if cfg.smtp.host == "" {
+		cfg.smtp.host = os.Getenv("SMTP_HOST")
+	}
+	if cfg.unsubscribe.secret == "" {
+		cfg.unsubscribe.secret = os.Getenv("UNSUBSCRIBE_SECRET")
+	}
+	if cfg.smtp.username == "" {
+		cfg.smtp.username = os.Getenv("SMTP_USERNAME")
+	}
+	if cfg.smtp.password == "" {
+		cfg.smtp.password = os.Getenv("SMTP_PASSWORD")
+	}
+
 	return cfg
 }
 
@@ -118,6 +206,40 @@ func setupLogger() *slog.Logger {
 }
 
 
+// newMailTransport returns the mail transport chosen on the command line.
+// Without one it is smtp when there is an SMTP host, and log in development.
+// Anywhere else it fails rather than silently sending no email
+func newMailTransport(cfg configuration, logger *slog.Logger) (mailer.Transport, error) {
+	transport := cfg.mailer.transport
+	if transport == "" {
+		switch {
+		case cfg.smtp.host != "":
+			transport = "smtp"
+		case cfg.env == "development":
+			transport = "log"
+		default:
+			return nil, fmt.Errorf("no SMTP host in the %s environment: set -smtp-host (or SMTP_HOST), or choose a -mailer-transport", cfg.env)
+		}
+	}
+
+	switch transport {
+	case "smtp":
+		if cfg.smtp.host == "" {
+			return nil, fmt.Errorf("smtp mailer transport needs -smtp-host (or SMTP_HOST)")
+		}
+		if cfg.smtp.username == "" || cfg.smtp.password == "" {
+			return nil, fmt.Errorf("smtp mailer transport needs -smtp-username and -smtp-password (or SMTP_USERNAME and SMTP_PASSWORD)")
+		}
+		return mailer.NewSMTPTransport(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password), nil
+	case "file":
+		return &mailer.FileTransport{Dir: cfg.mailer.dir}, nil
+	case "log":
+		return mailer.LogTransport{Logger: logger}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer transport %q", cfg.mailer.transport)
+	}
+}
+
 func openDB(settings configuration) (*sql.DB, error) {
 	// open a connection pool
 	db, err := sql.Open("postgres", settings.db.dsn)
@@ -154,6 +276,19 @@ func main() {
 	// Initialize logger
 	logger := setupLogger()
 
+	// a random secret would break the links on every restart, and between
+	// instances
+	if cfg.unsubscribe.secret == "" {
+		logger.Error("no unsubscribe secret: set -unsubscribe-secret or UNSUBSCRIBE_SECRET")
+		os.Exit(1)
+	}
+
+	transport, err := newMailTransport(cfg, logger)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
 	// Call to openDB() sets up our connection pool
 	db, err := openDB(cfg)
 	if err != nil {
@@ -188,12 +323,39 @@ func main() {
 		logger:     logger,
 		quoteModel: data.QuoteModel{DB: db},
 		userModel:  data.UserModel{DB: db},
-		mailer: mailer.New(cfg.smtp.host, cfg.smtp.port,
-			cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		mailer:     mailer.New(transport, cfg.smtp.sender),
 		studysessionModel: data.StudySessionModel{DB: db},
 		goalModel:         data.GoalModel{DB: db},
 		tokenModel:        data.TokenModel{DB: db},
 		permissionModel:   data.PermissionModel{DB: db},
+		statsModel:        data.StatsModel{DB: db},
+		milestoneModel:    data.MilestoneModel{DB: db},
+		habitModel:        data.HabitModel{DB: db},
+		reminderModel:     data.ReminderModel{DB: db},
+		jobModel:          data.JobModel{DB: db},
+		digestModel:       data.DigestModel{DB: db},
+		followModel:       data.FollowModel{DB: db},
+		searchModel:       data.SearchModel{DB: db},
+
+		notificationPreferenceModel: data.NotificationPreferenceModel{DB: db},
+		notificationModel:           data.NotificationModel{DB: db},
+		eventModel:                  data.EventModel{DB: db},
+		events:                      newEventBroker(),
+	}
+	app.notifier = multiNotifier{
+		channels: []channel{
+			emailNotifier{
+				jobs:           app.jobModel,
+				prefs:          app.notificationPreferenceModel,
+				unsubscribeURL: app.unsubscribeURL,
+			},
+			inboxNotifier{
+				notifications: app.notificationModel,
+				prefs:         app.notificationPreferenceModel,
+				mailer:        app.mailer,
+			},
+		},
+		logger: logger,
 	}
 	mux := http.NewServeMux()
 
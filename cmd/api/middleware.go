@@ -207,13 +207,11 @@ func (a *application) authenticate(next http.Handler) http.Handler {
 // Note: 401 is Unauthorized  and 403 is Forbidden (
 
 func (a *application) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
-	message := "you must be authenticated to access this resource"
-	a.errorResponseJSON(w, r, http.StatusUnauthorized, message)
+	a.localizedErrorResponse(w, r, http.StatusUnauthorized, "authentication_required")
 }
 
 func (a *application) inactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
-	message := "your user account must be activated to access this resource"
-	a.errorResponseJSON(w, r, http.StatusForbidden, message)
+	a.localizedErrorResponse(w, r, http.StatusForbidden, "inactive_account")
 }
 
 // This middleware checks if the user is authenticated (not anonymous)
@@ -0,0 +1,241 @@
+// Filename: cmd/api/milestones.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// readOwnedGoal loads the goal from the :id URL parameter and makes sure it
+// belongs to the logged-in user. It sends the error response itself and
+// returns nil if the goal can't be used
+func (app *application) readOwnedGoal(w http.ResponseWriter, r *http.Request) *data.Goal {
+	goalID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil
+	}
+
+	goal, err := app.goalModel.Get(goalID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil
+	}
+
+	// someone else's goal is reported as missing so ids can't be probed
+	user := app.contextGetUser(r)
+	if goal.UserID != user.ID {
+		app.notFoundResponse(w, r)
+		return nil
+	}
+
+	return goal
+}
+
+// List the milestones of a goal in order
+func (app *application) listMilestonesHandler(w http.ResponseWriter, r *http.Request) {
+	goal := app.readOwnedGoal(w, r)
+	if goal == nil {
+		return
+	}
+
+	milestones, err := app.milestoneModel.GetAllForGoal(goal.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"milestones": milestones}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Add a milestone to a goal. Without a position it goes to the end
+func (app *application) createMilestoneHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		Title       string    `json:"title"`
+		Position    int       `json:"position"`
+		DueDate     time.Time `json:"due_date"`
+		IsCompleted bool      `json:"is_completed"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+	milestone := &data.Milestone{
+		Title:       incomingData.Title,
+		Position:    incomingData.Position,
+		IsCompleted: incomingData.IsCompleted,
+	}
+	if !incomingData.DueDate.IsZero() {
+		milestone.DueDate = calendarDate(incomingData.DueDate, user.Location())
+	}
+
+	v := validator.New()
+	data.ValidateMilestone(v, milestone)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	goal := app.readOwnedGoal(w, r)
+	if goal == nil {
+		return
+	}
+	milestone.GoalID = goal.ID
+
+	err = app.milestoneModel.Insert(milestone)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	goal, err = app.refreshGoalProgress(user.ID, goal.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/goals/%d/milestones/%d", goal.ID, milestone.ID))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"milestone": milestone, "goal": goal}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Update a milestone, including moving it to a new position
+func (app *application) updateMilestoneHandler(w http.ResponseWriter, r *http.Request) {
+	milestoneID, err := app.readInt64Param(r, "milestone_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	goal := app.readOwnedGoal(w, r)
+	if goal == nil {
+		return
+	}
+
+	milestone, err := app.milestoneModel.Get(goal.ID, milestoneID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var incomingData struct {
+		Title       *string    `json:"title"`
+		Position    *int       `json:"position"`
+		DueDate     *time.Time `json:"due_date"`
+		IsCompleted *bool      `json:"is_completed"`
+	}
+
+	err = app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+	if incomingData.Title != nil {
+		milestone.Title = *incomingData.Title
+	}
+	if incomingData.Position != nil {
+		milestone.Position = *incomingData.Position
+	}
+	if incomingData.DueDate != nil {
+		milestone.DueDate = calendarDate(*incomingData.DueDate, user.Location())
+	}
+	if incomingData.IsCompleted != nil {
+		milestone.IsCompleted = *incomingData.IsCompleted
+	}
+
+	v := validator.New()
+	data.ValidateMilestone(v, milestone)
+	v.Check(milestone.Position >= 1, "position", "min_value", 1)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	err = app.milestoneModel.Update(milestone)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// completing the last milestone completes the goal
+	goal, err = app.refreshGoalProgress(user.ID, goal.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"milestone": milestone, "goal": goal}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Delete a milestone from a goal
+func (app *application) deleteMilestoneHandler(w http.ResponseWriter, r *http.Request) {
+	milestoneID, err := app.readInt64Param(r, "milestone_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	goal := app.readOwnedGoal(w, r)
+	if goal == nil {
+		return
+	}
+
+	err = app.milestoneModel.Delete(goal.ID, milestoneID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// the remaining milestones may now all be complete
+	_, err = app.goalModel.CompleteReached(goal.UserID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "milestone successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
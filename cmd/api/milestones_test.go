@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestCreateMilestoneHandler_BadJSON(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/goals/1/milestones", bytes.NewBufferString("{bad json"))
+	usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+	req = app.contextSetUser(req, usr)
+	rr := httptest.NewRecorder()
+
+	app.createMilestoneHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateMilestoneHandler_InvalidData(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"missing title", `{"due_date":"2025-12-01T00:00:00Z"}`},
+		{"missing due date", `{"title":"Read chapter 1"}`},
+		{"negative position", `{"title":"Read chapter 1","due_date":"2025-12-01T00:00:00Z","position":-1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp()
+			req := httptest.NewRequest(http.MethodPost, "/v1/goals/1/milestones", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+			req = app.contextSetUser(req, usr)
+			rr := httptest.NewRecorder()
+
+			app.createMilestoneHandler(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateMilestoneHandler_InvalidID(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPatch, "/v1/goals/1/milestones/", nil)
+	rr := httptest.NewRecorder()
+
+	app.updateMilestoneHandler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,245 @@
+// Filename: cmd/api/moderation.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// Moderators review the quotes other users submit
+const moderatePermission = "quotes:moderate"
+
+// newQuoteStatus is the status of a quote the user submits or edits.
+// Moderators' quotes don't need reviewing
+func (app *application) newQuoteStatus(userID int64) (string, error) {
+	moderator, err := app.permissionModel.HasForUser(userID, moderatePermission)
+	if err != nil {
+		return "", err
+	}
+	if moderator {
+		return data.QuoteApproved, nil
+	}
+	return data.QuotePending, nil
+}
+
+// getVisibleQuote reads the quote in the URL for the request's user. When it
+// doesn't exist or they can't see it, the response is written and ok is
+// false. Moderators can also see quotes that aren't approved yet, unless
+// they're private
+func (app *application) getVisibleQuote(w http.ResponseWriter, r *http.Request) (quote *data.Quote, ok bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	user := app.contextGetUser(r)
+	quote, err = app.quoteModel.GetByID(id, user.ID, false)
+	if errors.Is(err, data.ErrRecordNotFound) {
+		// only look the permission up when it makes a difference
+		var moderator bool
+		moderator, err = app.permissionModel.HasForUser(user.ID, moderatePermission)
+		if err == nil {
+			err = data.ErrRecordNotFound
+			if moderator {
+				quote, err = app.quoteModel.GetByID(id, user.ID, true)
+			}
+		}
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return quote, true
+}
+
+// GET /v1/moderation/quotes?status=pending
+// The moderation queue, oldest first. status picks which quotes are listed,
+// and reports sorts by how many unresolved reports they have
+func (app *application) listModerationQueueHandler(w http.ResponseWriter, r *http.Request) {
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	status := app.getSingleQueryParameter(queryParameters, "status", data.QuotePending)
+	data.ValidateQuoteStatus(v, status)
+
+	filters := data.Filters{
+		Page:         app.getSingleIntegerParameter(queryParameters, "page", 1, v),
+		PageSize:     app.getSingleIntegerParameter(queryParameters, "page_size", 20, v),
+		Sort:         app.getSingleQueryParameter(queryParameters, "sort", "id"),
+		SortSafeList: []string{"id", "reports", "popular", "-id", "-reports", "-popular"},
+	}
+	data.ValidateFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	quotes, metadata, err := app.quoteModel.ModerationQueue(status, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	responseData := envelope{
+		"@metadata": metadata,
+		"quotes":    quotes,
+	}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// PATCH /v1/moderation/quotes/:id
+// Sets the quote's status with {"status": ..., "reason": ...}. A reason is
+// required to reject it. The author is told when the status changes
+func (app *application) moderateQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	moderator := app.contextGetUser(r)
+
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var incomingData struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+
+	err = app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidateModeration(v, incomingData.Status, incomingData.Reason)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	previous, err := app.quoteModel.GetByID(id, moderator.ID, true)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	quote, err := app.quoteModel.Moderate(id, moderator.ID, incomingData.Status, incomingData.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	quote.Username = previous.Username
+
+	if quote.Status != previous.Status {
+		app.notifyQuoteModerated(quote)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"quote": quote}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// POST /v1/quotes/:id/report
+// Reports the quote with an optional {"reason": ...}. After enough reports
+// the quote is hidden until a moderator reviews it
+func (app *application) reportQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	quote, ok := app.getVisibleQuote(w, r)
+	if !ok {
+		return
+	}
+
+	var incomingData struct {
+		Reason string `json:"reason"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(quote.UserID != user.ID, "quote_id", "own_quote")
+	data.ValidateReportReason(v, incomingData.Reason)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	queued, err := app.quoteModel.Report(user.ID, quote.ID, incomingData.Reason, app.config.quotes.reportThreshold)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if queued {
+		quote.Status = data.QuotePending
+		quote.ModerationReason = data.ReasonReported
+		app.notifyQuoteModerated(quote)
+	}
+
+	// whether the quote was hidden isn't the reporter's business
+	err = app.writeJSON(w, http.StatusOK, envelope{"quote_id": quote.ID, "reported": true}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// notifyQuoteModerated tells the author about the quote's new status.
+// Failing to is logged rather than failing the request
+func (app *application) notifyQuoteModerated(quote *data.Quote) {
+	author, err := app.userModel.GetByID(quote.UserID)
+	if err != nil {
+		app.logger.Error("notifying quote author", "quote_id", quote.ID, "error", err.Error())
+		return
+	}
+
+	err = app.notifier.Notify(Notification{
+		UserID:   author.ID,
+		Email:    author.Email,
+		Template: "quote_moderated.tmpl",
+		Locale:   author.Locale,
+		Category: data.CategoryModeration,
+		Data: map[string]any{
+			"username": author.Username,
+			"quoteID":  quote.ID,
+			"content":  quote.Content,
+			"status":   quote.Status,
+			"reason":   quote.ModerationReason,
+		},
+	})
+	if err != nil {
+		app.logger.Error("notifying quote author", "quote_id", quote.ID, "error", err.Error())
+	}
+}
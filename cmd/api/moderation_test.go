@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+func TestListModerationQueueHandler_InvalidStatus(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/moderation/quotes?status=hidden", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.listModerationQueueHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestModerateQuoteHandler_RejectNeedsReason(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPatch, "/v1/moderation/quotes/5", strings.NewReader(`{"status": "rejected"}`))
+	req = withIDParam(app.contextSetUser(req, &data.User{ID: 1}), "5")
+	rr := httptest.NewRecorder()
+
+	app.moderateQuoteHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"reason"`) {
+		t.Fatalf("expected an error for reason; body=%s", rr.Body.String())
+	}
+}
+
+func TestModerateQuoteHandler_InvalidID(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPatch, "/v1/moderation/quotes/abc", strings.NewReader(`{"status": "approved"}`))
+	req = withIDParam(app.contextSetUser(req, &data.User{ID: 1}), "abc")
+	rr := httptest.NewRecorder()
+
+	app.moderateQuoteHandler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
+
+func TestReportQuoteHandler_InvalidID(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/quotes/0/report", nil)
+	req = withIDParam(app.contextSetUser(req, &data.User{ID: 1}), "0")
+	rr := httptest.NewRecorder()
+
+	app.reportQuoteHandler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
+
+func TestValidateModeration(t *testing.T) {
+	tests := []struct {
+		status, reason string
+		valid          bool
+	}{
+		{data.QuoteApproved, "", true},
+		{data.QuotePending, "", true},
+		{data.QuoteRejected, "Not a quote", true},
+		{data.QuoteRejected, "", false},
+		{"", "", false},
+		{"hidden", "", false},
+		{data.QuoteApproved, strings.Repeat("x", 501), false},
+	}
+	for _, tt := range tests {
+		v := validator.New()
+		data.ValidateModeration(v, tt.status, tt.reason)
+		if v.Valid() != tt.valid {
+			t.Errorf("ValidateModeration(%q, %d byte reason) valid = %v; want %v", tt.status, len(tt.reason), v.Valid(), tt.valid)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Filename: cmd/api/notifications.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// GET /v1/users/me/notifications
+func (app *application) showNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	prefs, err := app.notificationPreferenceModel.Get(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"notifications": prefs}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// PATCH /v1/users/me/notifications
+// Only the categories sent are changed. quiet_hours replaces the quiet hours,
+// and null removes them
+func (app *application) updateNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var incomingData struct {
+		Channels   map[string]map[string]bool `json:"channels"`
+		QuietHours json.RawMessage            `json:"quiet_hours"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	prefs, err := app.notificationPreferenceModel.Get(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	for channel, categories := range incomingData.Channels {
+		if prefs.Channels[channel] == nil {
+			prefs.Channels[channel] = map[string]bool{}
+		}
+		for category, enabled := range categories {
+			prefs.Channels[channel][category] = enabled
+		}
+	}
+
+	if incomingData.QuietHours != nil {
+		prefs.QuietHours = nil
+		if !bytes.Equal(incomingData.QuietHours, []byte("null")) {
+			err = json.Unmarshal(incomingData.QuietHours, &prefs.QuietHours)
+			if err != nil {
+				app.badRequestResponse(w, r, fmt.Errorf("the body contains the incorrect JSON type for field %q", "quiet_hours"))
+				return
+			}
+		}
+	}
+
+	// only what was sent is validated and saved
+	changed := &data.NotificationPreferences{
+		Channels:   incomingData.Channels,
+		QuietHours: prefs.QuietHours,
+	}
+
+	v := validator.New()
+	data.ValidateNotificationPreferences(v, changed)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	err = app.notificationPreferenceModel.Update(user.ID, changed)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"notifications": prefs}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+func TestQuietUntil(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 12, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		now   time.Time
+		want  time.Time
+	}{
+		{"before", "13:00", "15:00", at(12, 59), time.Time{}},
+		{"inside", "13:00", "15:00", at(14, 0), at(15, 0)},
+		{"at the end", "13:00", "15:00", at(15, 0), time.Time{}},
+		{"overnight before midnight", "22:00", "07:00", at(23, 30), at(7, 0).AddDate(0, 0, 1)},
+		{"overnight after midnight", "22:00", "07:00", at(6, 15), at(7, 0)},
+		{"overnight outside", "22:00", "07:00", at(12, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := data.DefaultNotificationPreferences()
+			prefs.TimeZone = "UTC"
+			prefs.QuietHours = &data.QuietHours{Start: tt.start, End: tt.end}
+
+			got := prefs.QuietUntil(tt.now)
+			if !got.Equal(tt.want) {
+				t.Errorf("QuietUntil(%s) = %s; want %s", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNotificationPreferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		prefs data.NotificationPreferences
+		key   string
+		code  string
+	}{
+		{
+			name:  "security off",
+			prefs: data.NotificationPreferences{Channels: map[string]map[string]bool{"email": {"security": false}}},
+			key:   "channels.email.security",
+			code:  "mandatory_notification",
+		},
+		{
+			name:  "unknown channel",
+			prefs: data.NotificationPreferences{Channels: map[string]map[string]bool{"sms": {"reminders": true}}},
+			key:   "channels.sms",
+			code:  "unknown_channel",
+		},
+		{
+			name:  "unknown category",
+			prefs: data.NotificationPreferences{Channels: map[string]map[string]bool{"email": {"marketing": false}}},
+			key:   "channels.email.marketing",
+			code:  "one_of",
+		},
+		{
+			name:  "bad time",
+			prefs: data.NotificationPreferences{QuietHours: &data.QuietHours{Start: "24:00", End: "07:00"}},
+			key:   "quiet_hours.start",
+			code:  "invalid_time_of_day",
+		},
+		{
+			name:  "empty window",
+			prefs: data.NotificationPreferences{QuietHours: &data.QuietHours{Start: "07:00", End: "07:00"}},
+			key:   "quiet_hours.end",
+			code:  "same_as_start",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			data.ValidateNotificationPreferences(v, &tt.prefs)
+
+			if got := v.Errors[tt.key].Code; got != tt.code {
+				t.Errorf("expected %s error %q; got %v", tt.key, tt.code, v.Errors)
+			}
+		})
+	}
+
+	v := validator.New()
+	data.ValidateNotificationPreferences(v, &data.NotificationPreferences{
+		Channels:   map[string]map[string]bool{"email": {"reminders": false, "security": true}},
+		QuietHours: &data.QuietHours{Start: "22:00", End: "07:00"},
+	})
+	if !v.Valid() {
+		t.Errorf("expected valid preferences; got %v", v.Errors)
+	}
+}
@@ -0,0 +1,165 @@
+// Filename: cmd/api/notifier.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/mailer"
+)
+
+// Notification is a message for one user. Template names the message
+// template, Locale picks its translation and Data fills it in. Category
+// decides whether the user's preferences and quiet hours apply, and a
+// notification held back by quiet hours is dropped if it would go out after
+// NotAfter
+type Notification struct {
+	UserID   int64
+	Email    string
+	Template string
+	Locale   string
+	Category string
+	NotAfter time.Time
+	Data     map[string]any
+}
+
+// notifier delivers notifications to users. The handlers and schedulers only
+// depend on this, so other channels can be added next to email
+type notifier interface {
+	Notify(n Notification) error
+}
+
+// channel is one way of delivering notifications. It reports whether the
+// notification went out, which it doesn't when the user turned the category
+// off there
+type channel interface {
+	deliver(n Notification) (bool, error)
+}
+
+// multiNotifier delivers each notification on every channel, independently
+// of each other. Once any channel has delivered it, the failures of the
+// others are only logged, so that a caller retrying the notification doesn't
+// send it twice on the channels that worked. It only fails when nothing went
+// out
+type multiNotifier struct {
+	channels []channel
+	logger   *slog.Logger
+}
+
+func (m multiNotifier) Notify(n Notification) error {
+	delivered := false
+	var errs []error
+	for _, c := range m.channels {
+		ok, err := c.deliver(n)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delivered = delivered || ok
+	}
+
+	if !delivered {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		m.logger.Error("delivering notification", "user_id", n.UserID, "template", n.Template, "error", err.Error())
+	}
+	return nil
+}
+
+// emailNotifier queues notifications as emails for the job workers, which
+// retry them if the mail server is unavailable. Apart from security emails
+// it skips categories the user turned off, holds emails back until their
+// quiet hours end and adds an unsubscribe link
+type emailNotifier struct {
+	jobs           data.JobModel
+	prefs          data.NotificationPreferenceModel
+	unsubscribeURL func(userID int64, category string) string
+}
+
+func (e emailNotifier) deliver(n Notification) (bool, error) {
+	runAt := time.Now()
+
+	if n.Category != data.CategorySecurity {
+		prefs, err := e.prefs.Get(n.UserID)
+		if err != nil {
+			return false, err
+		}
+		if !prefs.Enabled(data.ChannelEmail, n.Category) {
+			return false, nil
+		}
+		if until := prefs.QuietUntil(runAt); !until.IsZero() {
+			if !n.NotAfter.IsZero() && until.After(n.NotAfter) {
+				return false, nil
+			}
+			runAt = until
+		}
+
+		n.Data = maps.Clone(n.Data)
+		if n.Data == nil {
+			n.Data = map[string]any{}
+		}
+		n.Data["unsubscribeURL"] = e.unsubscribeURL(n.UserID, n.Category)
+	}
+
+	_, err := e.jobs.EnqueueAt(data.JobSendEmail, data.SendEmailPayload{
+		Recipient: n.Email,
+		Template:  n.Template,
+		Locale:    n.Locale,
+		Data:      n.Data,
+	}, runAt)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// inboxNotifier keeps notifications in the user's in-app inbox, titled with
+// the subject of the matching email template. Security notifications are
+// left to email, and quiet hours don't apply since nothing is pushed
+type inboxNotifier struct {
+	notifications data.NotificationModel
+	prefs         data.NotificationPreferenceModel
+	mailer        mailer.Mailer
+}
+
+func (i inboxNotifier) deliver(n Notification) (bool, error) {
+	if n.Category == data.CategorySecurity {
+		return false, nil
+	}
+
+	prefs, err := i.prefs.Get(n.UserID)
+	if err != nil {
+		return false, err
+	}
+	if !prefs.Enabled(data.ChannelInApp, n.Category) {
+		return false, nil
+	}
+
+	msg, err := i.mailer.Render(n.Email, n.Template, n.Locale, n.Data)
+	if err != nil {
+		return false, err
+	}
+
+	body, err := json.Marshal(n.Data)
+	if err != nil {
+		return false, err
+	}
+
+	err = i.notifications.Insert(&data.Notification{
+		UserID:   n.UserID,
+		Category: n.Category,
+		Kind:     strings.TrimSuffix(n.Template, ".tmpl"),
+		Title:    msg.Subject,
+		Data:     body,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
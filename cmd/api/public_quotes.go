@@ -0,0 +1,52 @@
+// Filename: cmd/api/public_quotes.go
+package main
+
+import (
+	"net/http"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// GET /v1/quotes/public
+// The approved public quotes, for visitors who aren't signed in. It takes
+// the same search and paging parameters as the quote list, and shows the
+// same to signed in users so links to it look the same for everyone
+func (app *application) publicQuotesHandler(w http.ResponseWriter, r *http.Request) {
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	search := data.QuoteSearch{
+		Content:     app.getSingleQueryParameter(queryParameters, "content", ""),
+		Tags:        data.NormalizeTags(app.getMultipleQueryParameters(queryParameters, "tag", []string{})),
+		Author:      app.getSingleQueryParameter(queryParameters, "author", ""),
+		SubmittedBy: app.getSingleQueryParameter(queryParameters, "submitted_by", ""),
+	}
+	filters := data.Filters{
+		Page:         app.getSingleIntegerParameter(queryParameters, "page", 1, v),
+		PageSize:     app.getSingleIntegerParameter(queryParameters, "page_size", 15, v),
+		Sort:         app.getSingleQueryParameter(queryParameters, "sort", "-id"),
+		SortSafeList: []string{"id", "popular", "-id", "-popular"},
+	}
+	data.ValidateQuoteSearch(v, search)
+	data.ValidateFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	quotes, metadata, err := app.quoteModel.GetAll(data.AnonymousUser.ID, search, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	responseData := envelope{
+		"@metadata": metadata,
+		"quotes":    quotes,
+	}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+func TestPublicQuotesHandler_InvalidSort(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/quotes/public?sort=user_id", nil)
+	req = app.contextSetUser(req, data.AnonymousUser)
+	rr := httptest.NewRecorder()
+
+	app.publicQuotesHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateQuotesHandler_InvalidVisibility(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/quotes", strings.NewReader(`{"content": "Keep going", "visibility": "friends"}`))
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.createQuotesHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"visibility"`) {
+		t.Fatalf("expected an error for visibility; body=%s", rr.Body.String())
+	}
+}
+
+func TestValidateVisibility(t *testing.T) {
+	for _, visibility := range []string{"", "friends", "Public"} {
+		v := validator.New()
+		data.ValidateVisibility(v, visibility)
+		if v.Valid() {
+			t.Errorf("ValidateVisibility(%q) is valid; want invalid", visibility)
+		}
+	}
+	for _, visibility := range data.QuoteVisibilities {
+		v := validator.New()
+		data.ValidateVisibility(v, visibility)
+		if !v.Valid() {
+			t.Errorf("ValidateVisibility(%q) is invalid; want valid", visibility)
+		}
+	}
+}
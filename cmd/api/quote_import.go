@@ -0,0 +1,403 @@
+// Filename: cmd/api/quote_import.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// Import and export formats
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+)
+
+// The largest file an import can upload
+const maxImportBytes = 2 << 20
+
+// The columns of an export, which an import reads back by name
+var quoteCSVHeader = []string{"id", "content", "author", "source", "tags", "submitted_by", "status", "visibility", "likes", "created_at"}
+
+var errMissingContentColumn = errors.New("the CSV header has no content column")
+
+// Spreadsheets read a cell starting with one of these as a formula
+const csvFormulaStart = "=+-@\t\r"
+
+// csvCell keeps a spreadsheet from running an exported value as a formula
+// by starting it with a quote mark. Imports take the mark off again
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaStart, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// importRow is a row read from an import file. line is where it starts in
+// the file, and quote is nil when the row couldn't be read
+type importRow struct {
+	line  int
+	quote *data.Quote
+}
+
+// importReportRow is what happened to one row. Status is created,
+// duplicate or invalid
+type importReportRow struct {
+	Line        int                          `json:"line"`
+	Status      string                       `json:"status"`
+	QuoteID     int64                        `json:"quote_id,omitempty"`
+	DuplicateOf int64                        `json:"duplicate_of,omitempty"`
+	Errors      map[string]validator.Message `json:"errors,omitempty"`
+}
+
+// importFormat picks the format from the format query parameter, or else
+// the Content-Type
+func importFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return formatCSV
+	case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return formatJSONL
+	}
+	return ""
+}
+
+// parseQuoteCSV reads a CSV file with a header row. content is the only
+// column needed, tags are comma separated and other columns are ignored, so
+// an export can be imported again
+func parseQuoteCSV(body io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		// spreadsheets often start the file with a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["content"]; !ok {
+		return nil, errMissingContentColumn
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		value := strings.TrimSpace(record[i])
+		if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaStart, rune(value[1])) {
+			value = value[1:]
+		}
+		return value
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		var parseError *csv.ParseError
+		switch {
+		case errors.As(err, &parseError):
+			rows = append(rows, importRow{line: parseError.StartLine})
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, importRow{line: line, quote: &data.Quote{
+			Content:    field(record, "content"),
+			Author:     field(record, "author"),
+			Source:     field(record, "source"),
+			Tags:       data.NormalizeTags(strings.Split(field(record, "tags"), ",")),
+			Visibility: field(record, "visibility"),
+		}})
+	}
+}
+
+// parseQuoteJSONL reads one JSON object per line. Blank lines are skipped
+// and unknown keys ignored, so an export can be imported again
+func parseQuoteJSONL(body io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, maxImportBytes)
+
+	var rows []importRow
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var incoming struct {
+			Content    string   `json:"content"`
+			Author     string   `json:"author"`
+			Source     string   `json:"source"`
+			Tags       []string `json:"tags"`
+			Visibility string   `json:"visibility"`
+		}
+		if err := json.Unmarshal(text, &incoming); err != nil {
+			rows = append(rows, importRow{line: line})
+			continue
+		}
+
+		rows = append(rows, importRow{line: line, quote: &data.Quote{
+			Content:    strings.TrimSpace(incoming.Content),
+			Author:     strings.TrimSpace(incoming.Author),
+			Source:     strings.TrimSpace(incoming.Source),
+			Tags:       data.NormalizeTags(incoming.Tags),
+			Visibility: strings.TrimSpace(incoming.Visibility),
+		}})
+	}
+
+	return rows, scanner.Err()
+}
+
+// POST /v1/quotes/import?format=&dry_run=
+// Imports a CSV or JSON Lines file of quotes, picked by format or the
+// Content-Type. Every row is validated and near-duplicates of existing
+// quotes, or of earlier rows, are skipped. The rest are inserted together,
+// and the response reports what happened to each row by its line in the
+// file. dry_run=true reports without inserting anything
+func (app *application) importQuotesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	v := validator.New()
+	format := importFormat(r)
+	dryRun := false
+	switch app.getSingleQueryParameter(r.URL.Query(), "dry_run", "") {
+	case "", "false":
+	case "true":
+		dryRun = true
+	default:
+		v.AddError("dry_run", "boolean")
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	// uploading and inserting a large file takes longer than most requests
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Now().Add(30 * time.Second))
+	_ = rc.SetWriteDeadline(time.Now().Add(time.Minute))
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	var rows []importRow
+	var err error
+	switch format {
+	case formatCSV:
+		rows, err = parseQuoteCSV(body)
+	case formatJSONL:
+		rows, err = parseQuoteJSONL(body)
+	default:
+		app.localizedErrorResponse(w, r, http.StatusUnsupportedMediaType, "unsupported_import_format")
+		return
+	}
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		switch {
+		case errors.Is(err, errMissingContentColumn):
+			v.AddError("content", "missing_column")
+			app.failedValidationResponse(w, r, v)
+		case errors.As(err, &maxBytesError):
+			app.badRequestResponse(w, r, fmt.Errorf("the body must not be larger than %d bytes", maxBytesError.Limit))
+		default:
+			app.badRequestResponse(w, r, err)
+		}
+		return
+	}
+
+	v.Check(len(rows) > 0, "rows", "required")
+	v.Check(len(rows) <= data.MaxImportRows, "rows", "max_items", data.MaxImportRows)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	status, err := app.newQuoteStatus(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	lang := app.requestLanguage(r)
+	report := make([]importReportRow, len(rows))
+	var valid []*data.Quote
+	var validRows []int
+	for i, row := range rows {
+		report[i].Line = row.line
+
+		rv := validator.New()
+		if row.quote == nil {
+			rv.AddError("row", "malformed_row")
+		} else {
+			row.quote.UserID = user.ID
+			row.quote.Status = status
+			if row.quote.Visibility == "" {
+				row.quote.Visibility = data.VisibilityPublic
+			}
+			data.ValidateQuote(rv, row.quote)
+		}
+		if !rv.Valid() {
+			report[i].Status = "invalid"
+			report[i].Errors = rv.Localize(lang)
+			continue
+		}
+
+		valid = append(valid, row.quote)
+		validRows = append(validRows, i)
+	}
+
+	results, err := app.quoteModel.Import(user.ID, valid, dryRun)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	created, duplicates := 0, 0
+	for j, result := range results {
+		row := &report[validRows[j]]
+		if result.DuplicateOf != 0 {
+			row.Status = "duplicate"
+			row.DuplicateOf = result.DuplicateOf
+			duplicates++
+			continue
+		}
+		row.Status = "created"
+		if !dryRun {
+			row.QuoteID = result.QuoteID
+		}
+		created++
+	}
+
+	responseData := envelope{"import": envelope{
+		"dry_run":    dryRun,
+		"created":    created,
+		"duplicates": duplicates,
+		"invalid":    len(rows) - len(valid),
+		"rows":       report,
+	}}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// GET /v1/quotes/export?format=&show_all=
+// Streams the user's quotes, or with show_all=true every quote they can
+// see, as CSV (the default) or JSON Lines. The content, tag, author and
+// submitted_by filters of the quote list apply. CSV cells that would run as
+// spreadsheet formulas start with a quote mark
+func (app *application) exportQuotesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	format := app.getSingleQueryParameter(queryParameters, "format", formatCSV)
+	v.Check(validator.PermittedValue(format, formatCSV, formatJSONL), "format", "one_of", "csv, jsonl")
+
+	search := data.QuoteSearch{
+		Content:     app.getSingleQueryParameter(queryParameters, "content", ""),
+		Tags:        data.NormalizeTags(app.getMultipleQueryParameters(queryParameters, "tag", []string{})),
+		Author:      app.getSingleQueryParameter(queryParameters, "author", ""),
+		SubmittedBy: app.getSingleQueryParameter(queryParameters, "submitted_by", ""),
+	}
+	data.ValidateQuoteSearch(v, search)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+	showAll := app.getSingleQueryParameter(queryParameters, "show_all", "") == "true" || search.SubmittedBy != ""
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Now().Add(time.Minute))
+
+	// the headers go out with the first quote, so a query that fails
+	// straight away still gets an error response
+	started := false
+	var csvWriter *csv.Writer
+	start := func() error {
+		started = true
+		if format == formatJSONL {
+			w.Header().Set("Content-Type", "application/jsonl; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="quotes.jsonl"`)
+			return nil
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="quotes.csv"`)
+		csvWriter = csv.NewWriter(w)
+		return csvWriter.Write(quoteCSVHeader)
+	}
+
+	encoder := json.NewEncoder(w)
+	err := app.quoteModel.Export(user.ID, !showAll, search, func(quote *data.Quote) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if format == formatJSONL {
+			return encoder.Encode(quote)
+		}
+		err := csvWriter.Write([]string{
+			fmt.Sprint(quote.ID),
+			csvCell(quote.Content),
+			csvCell(quote.Author),
+			csvCell(quote.Source),
+			csvCell(strings.Join(quote.Tags, ", ")),
+			csvCell(quote.Username),
+			quote.Status,
+			quote.Visibility,
+			fmt.Sprint(quote.Likes),
+			quote.CreatedAt.Format(time.RFC3339),
+		})
+		csvWriter.Flush()
+		if err == nil {
+			err = csvWriter.Error()
+		}
+		return err
+	})
+	if err == nil && !started {
+		err = start()
+		if csvWriter != nil {
+			csvWriter.Flush()
+			err = errors.Join(err, csvWriter.Error())
+		}
+	}
+	if err != nil {
+		if !started {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		// the response has started, so all that can be done is to stop
+		app.logger.Error("exporting quotes", "user_id", user.ID, "error", err.Error())
+	}
+}
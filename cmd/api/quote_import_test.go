@@ -0,0 +1,169 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestParseQuoteCSV(t *testing.T) {
+	body := "\ufeffContent,Author,Tags,Likes\n" +
+		"\"Stay curious, always\",Einstein,\"Science, motivation\",4\n" +
+		"\"bad \"quote\"\n" +
+		"Short row\n"
+
+	rows, err := parseQuoteCSV(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows; want 3", len(rows))
+	}
+
+	first := rows[0]
+	if first.line != 2 || first.quote == nil {
+		t.Fatalf("first row = %+v; want a quote on line 2", first)
+	}
+	if first.quote.Content != "Stay curious, always" || first.quote.Author != "Einstein" {
+		t.Errorf("first quote = %+v", first.quote)
+	}
+	if !slices.Equal(first.quote.Tags, []string{"science", "motivation"}) {
+		t.Errorf("tags = %v; want [science motivation]", first.quote.Tags)
+	}
+
+	if rows[1].line != 3 || rows[1].quote != nil {
+		t.Errorf("second row = %+v; want an unreadable row on line 3", rows[1])
+	}
+	if rows[2].quote == nil || rows[2].quote.Content != "Short row" || rows[2].quote.Author != "" {
+		t.Errorf("third row = %+v; want a quote with only content", rows[2])
+	}
+}
+
+func TestParseQuoteCSV_MissingContentColumn(t *testing.T) {
+	_, err := parseQuoteCSV(strings.NewReader("quote,author\nHello,Me\n"))
+	if err != errMissingContentColumn {
+		t.Fatalf("err = %v; want errMissingContentColumn", err)
+	}
+}
+
+func TestParseQuoteJSONL(t *testing.T) {
+	body := `{"content": "Keep going", "tags": ["Motivation"], "likes": 3}` + "\n" +
+		"\n" +
+		`{"content": ` + "\n"
+
+	rows, err := parseQuoteJSONL(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows; want 2", len(rows))
+	}
+	if rows[0].line != 1 || rows[0].quote == nil || rows[0].quote.Content != "Keep going" {
+		t.Errorf("first row = %+v", rows[0])
+	}
+	if !slices.Equal(rows[0].quote.Tags, []string{"motivation"}) {
+		t.Errorf("tags = %v; want [motivation]", rows[0].quote.Tags)
+	}
+	if rows[1].line != 3 || rows[1].quote != nil {
+		t.Errorf("second row = %+v; want an unreadable row on line 3", rows[1])
+	}
+}
+
+func TestImportQuotesHandler_UnsupportedFormat(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/quotes/import", strings.NewReader("<quotes/>"))
+	req.Header.Set("Content-Type", "application/xml")
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.importQuotesHandler(rr, req)
+
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnsupportedMediaType, rr.Code, rr.Body.String())
+	}
+}
+
+func TestImportQuotesHandler_MissingContentColumn(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/quotes/import", strings.NewReader("quote\nHello\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.importQuotesHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestImportQuotesHandler_Empty(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/quotes/import?format=jsonl", strings.NewReader("\n\n"))
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.importQuotesHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestExportQuotesHandler_InvalidFormat(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/quotes/export?format=xlsx", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.exportQuotesHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestExportQuotesHandler_CSVFormulas(t *testing.T) {
+	// another user's quote, written to be run by a spreadsheet
+	db, _ := newFakeDB(func(query string, args []driver.Value) fakeResult {
+		row := []driver.Value{int64(4), int64(2), "bob", `=HYPERLINK("https://example.com","Click")`, int64(0),
+			"approved", "public", "", "+1 author", "@source", "{-focus}", time.Now()}
+		return fakeResult{columns: make([]string, len(row)), rows: [][]driver.Value{row}}
+	})
+	defer db.Close()
+
+	app := newTestApp()
+	app.quoteModel = data.QuoteModel{DB: db}
+	req := httptest.NewRequest(http.MethodGet, "/v1/quotes/export?show_all=true", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.exportQuotesHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	for _, cell := range []string{`"'=HYPERLINK(""https://example.com"",""Click"")"`, "'+1 author", "'@source", "'-focus"} {
+		if !strings.Contains(rr.Body.String(), cell) {
+			t.Errorf("export does not contain %s:\n%s", cell, rr.Body.String())
+		}
+	}
+
+	// importing the export again gives back the original values
+	rows, err := parseQuoteCSV(strings.NewReader(rr.Body.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].quote == nil {
+		t.Fatalf("rows = %+v; want one quote", rows)
+	}
+	if quote := rows[0].quote; quote.Content != `=HYPERLINK("https://example.com","Click")` || quote.Author != "+1 author" || quote.Source != "@source" {
+		t.Errorf("imported quote = %+v; want the values without the quote marks", quote)
+	}
+}
@@ -0,0 +1,44 @@
+// Filename: cmd/api/quote_tags.go
+package main
+
+import (
+	"net/http"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// GET /v1/quotes/tags
+// The tags in use with how many quotes have each, most used first
+func (app *application) listQuoteTagsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	filters := data.Filters{
+		Page:         app.getSingleIntegerParameter(queryParameters, "page", 1, v),
+		PageSize:     app.getSingleIntegerParameter(queryParameters, "page_size", 50, v),
+		Sort:         app.getSingleQueryParameter(queryParameters, "sort", "-count"),
+		SortSafeList: []string{"name", "count", "-name", "-count"},
+	}
+	data.ValidateFilters(v, filters)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	tags, metadata, err := app.quoteModel.Tags(user.ID, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	responseData := envelope{
+		"@metadata": metadata,
+		"tags":      tags,
+	}
+	err = app.writeJSON(w, http.StatusOK, responseData, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+func TestListQuoteTagsHandler_InvalidSort(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/quotes/tags?sort=id", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.listQuoteTagsHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestListQuotesHandler_InvalidTag(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/quotes?tag=study,no_underscores", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.listQuotesHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"tag"`) {
+		t.Fatalf("expected an error for tag; body=%s", rr.Body.String())
+	}
+}
+
+func TestRandomQuotesHandler_InvalidTag(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/quotes/random?tag=-leading-dash", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.randomQuotesHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
+
+func TestValidateQuote_Tags(t *testing.T) {
+	tests := map[string]struct {
+		tags  []string
+		valid bool
+	}{
+		"none":          {nil, true},
+		"normalized":    {data.NormalizeTags([]string{" Motivation ", "exam-prep", "MOTIVATION"}), true},
+		"spaces":        {[]string{"exam prep"}, false},
+		"too long":      {[]string{strings.Repeat("a", 31)}, false},
+		"too many tags": {[]string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}, false},
+	}
+	for name, tt := range tests {
+		v := validator.New()
+		data.ValidateQuote(v, &data.Quote{UserID: 1, Content: "Keep going", Tags: tt.tags, Visibility: data.VisibilityPublic})
+		if v.Valid() != tt.valid {
+			t.Errorf("%s: valid = %v; want %v (errors %v)", name, v.Valid(), tt.valid, v.Errors)
+		}
+	}
+}
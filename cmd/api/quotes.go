@@ -18,7 +18,11 @@ func (app *application) createQuotesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	var incomingData struct {
-		Content string `json:"content"`
+		Content    string   `json:"content"`
+		Author     string   `json:"author"`
+		Source     string   `json:"source"`
+		Tags       []string `json:"tags"`
+		Visibility string   `json:"visibility"`
 	}
 
 	err := app.readJSON(w, r, &incomingData)
@@ -28,15 +32,29 @@ func (app *application) createQuotesHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	quote := &data.Quote{
-		UserID:  user.ID,
-		Content: incomingData.Content,
+		UserID:     user.ID,
+		Content:    incomingData.Content,
+		Author:     incomingData.Author,
+		Source:     incomingData.Source,
+		Tags:       data.NormalizeTags(incomingData.Tags),
+		Visibility: incomingData.Visibility,
+	}
+	if quote.Visibility == "" {
+		quote.Visibility = data.VisibilityPublic
 	}
 
 	// Validate the quote data
 	v := validator.New()
 	data.ValidateQuote(v, quote)
 	if !v.Valid() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	// Quotes wait for a moderator unless a moderator wrote them
+	quote.Status, err = app.newQuoteStatus(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
@@ -61,27 +79,15 @@ func (app *application) createQuotesHandler(w http.ResponseWriter, r *http.Reque
 
 // Display the users quote based on ID
 func (app *application) displayQuotesHandler(w http.ResponseWriter, r *http.Request) {
-	// get id from the url
-	id, err := app.readIDParam(r)
-	if err != nil {
-		app.notFoundResponse(w, r)
-		return
-	}
-
-	quote, err := app.quoteModel.Get(id)
-	if err != nil {
-		switch {
-		case errors.Is(err, data.ErrRecordNotFound):
-			app.notFoundResponse(w, r)
-		default:
-			app.serverErrorResponse(w, r, err)
-		}
+	// only approved quotes are shown to other users
+	quote, ok := app.getVisibleQuote(w, r)
+	if !ok {
 		return
 	}
 
 	// send the quote as json response
 	data := envelope{"quote": quote}
-	err = app.writeJSON(w, http.StatusOK, data, nil)
+	err := app.writeJSON(w, http.StatusOK, data, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -98,8 +104,8 @@ func (app *application) listQuotesHandler(w http.ResponseWriter, r *http.Request
     }
 
     var queryParametersData struct {
-       Content string
        ShowAll bool // if true, show all quotes (for random quote feature)
+       data.QuoteSearch
        data.Filters
     }
 
@@ -110,17 +116,26 @@ func (app *application) listQuotesHandler(w http.ResponseWriter, r *http.Request
 	queryParametersData.Content = app.getSingleQueryParameter(queryParameters, "content", "")
 	// Check if we should show all quotes (for random quote feature on homepage)
 	queryParametersData.ShowAll = app.getSingleQueryParameter(queryParameters, "show_all", "") == "true"
+	// tag=a,b keeps quotes with both tags, and submitted_by names another user
+	// so it always searches all quotes
+	queryParametersData.Tags = data.NormalizeTags(app.getMultipleQueryParameters(queryParameters, "tag", []string{}))
+	queryParametersData.Author = app.getSingleQueryParameter(queryParameters, "author", "")
+	queryParametersData.SubmittedBy = app.getSingleQueryParameter(queryParameters, "submitted_by", "")
+	if queryParametersData.SubmittedBy != "" {
+		queryParametersData.ShowAll = true
+	}
 
 	v := validator.New()
 	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
 	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 15, v)
 	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "id")
-	queryParametersData.Filters.SortSafeList = []string{"id", "user_id", "content", "-id", "-user_id", "-content"}
+	queryParametersData.Filters.SortSafeList = []string{"id", "user_id", "content", "popular", "-id", "-user_id", "-content", "-popular"}
 
 	// Check if the filters are valid
+	data.ValidateQuoteSearch(v, queryParametersData.QuoteSearch)
 	data.ValidateFilters(v, queryParametersData.Filters)
 	if !v.IsEmpty() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -130,9 +145,9 @@ func (app *application) listQuotesHandler(w http.ResponseWriter, r *http.Request
 
 	// If show_all is true, get all quotes; otherwise filter by user
 	if queryParametersData.ShowAll {
-    	quotes, metadata, err = app.quoteModel.GetAll(queryParametersData.Content, queryParametersData.Filters)
+    	quotes, metadata, err = app.quoteModel.GetAll(user.ID, queryParametersData.QuoteSearch, queryParametersData.Filters)
 	} else {
-    	quotes, metadata, err = app.quoteModel.GetAllForUser(user.ID, queryParametersData.Content, queryParametersData.Filters)
+    	quotes, metadata, err = app.quoteModel.GetAllForUser(user.ID, queryParametersData.QuoteSearch, queryParametersData.Filters)
 	}
 
 	if err != nil {
@@ -161,8 +176,9 @@ func (app *application) updateQuotesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// get the existing quote from the database
-	quote, err := app.quoteModel.GetByID(id)
+	// get the existing quote from the database, if the user can see it
+	user := app.contextGetUser(r)
+	quote, err := app.quoteModel.GetByID(id, user.ID, false)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -173,8 +189,18 @@ func (app *application) updateQuotesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// only the owner can change a quote. Moderators use the moderation queue
+	if quote.UserID != user.ID {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
 	var incomingData struct {
-		Content *string `json:"content"`
+		Content    *string  `json:"content"`
+		Author     *string  `json:"author"`
+		Source     *string  `json:"source"`
+		Tags       []string `json:"tags"`
+		Visibility *string  `json:"visibility"`
 	}
 
 	err = app.readJSON(w, r, &incomingData)
@@ -183,16 +209,39 @@ func (app *application) updateQuotesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// update the fields if provided
-	if incomingData.Content != nil {
+	// update the fields if provided. Changed text is reviewed again
+	changed := false
+	if incomingData.Content != nil && *incomingData.Content != quote.Content {
 		quote.Content = *incomingData.Content
+		changed = true
+	}
+	if incomingData.Author != nil && *incomingData.Author != quote.Author {
+		quote.Author = *incomingData.Author
+		changed = true
+	}
+	if incomingData.Source != nil && *incomingData.Source != quote.Source {
+		quote.Source = *incomingData.Source
+		changed = true
+	}
+	if incomingData.Tags != nil {
+		quote.Tags = data.NormalizeTags(incomingData.Tags)
+	}
+	if incomingData.Visibility != nil {
+		quote.Visibility = *incomingData.Visibility
+	}
+	if changed {
+		quote.Status, err = app.newQuoteStatus(user.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
 	}
 
 	// validate the updated quote data
 	v := validator.New()
 	data.ValidateQuote(v, quote)
 	if !v.IsEmpty() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -221,8 +270,8 @@ func (app *application) deleteQuotesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// delete the quote from the database
-	err = app.quoteModel.Delete(id)
+	// delete the quote from the database. Only the owner's own quotes go
+	err = app.quoteModel.Delete(id, app.contextGetUser(r).ID)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
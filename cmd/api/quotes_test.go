@@ -2,21 +2,16 @@ package main
 
 import (
     "bytes"
+    "database/sql/driver"
     "net/http"
     "net/http/httptest"
     "testing"
-    "io"
-    "log/slog"
+    "time"
     "github.com/aiycoleman/Study-Mate/internal/data"
 )
 
-func newTestAppQuotes() *application {
-    logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-    return &application{logger: logger}
-}
-
 func TestCreateQuotesHandler_BadJSON(t *testing.T) {
-    app := newTestAppQuotes()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodPost, "/v1/quotes", bytes.NewBufferString("{bad json"))
     // set an authenticated user in context to avoid panic
     usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
@@ -31,7 +26,7 @@ func TestCreateQuotesHandler_BadJSON(t *testing.T) {
 }
 
 func TestCreateQuotesHandler_InvalidData(t *testing.T) {
-    app := newTestAppQuotes()
+    app := newTestApp()
     payload := `{"content":""}`
     req := httptest.NewRequest(http.MethodPost, "/v1/quotes", bytes.NewBufferString(payload))
     req.Header.Set("Content-Type", "application/json")
@@ -48,7 +43,7 @@ func TestCreateQuotesHandler_InvalidData(t *testing.T) {
 }
 
 func TestDisplayQuotesHandler_InvalidID(t *testing.T) {
-    app := newTestAppQuotes()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodGet, "/v1/quotes/", nil)
     rr := httptest.NewRecorder()
 
@@ -60,7 +55,7 @@ func TestDisplayQuotesHandler_InvalidID(t *testing.T) {
 }
 
 func TestUpdateQuotesHandler_InvalidID(t *testing.T) {
-    app := newTestAppQuotes()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodPatch, "/v1/quotes/", nil)
     rr := httptest.NewRecorder()
 
@@ -72,7 +67,7 @@ func TestUpdateQuotesHandler_InvalidID(t *testing.T) {
 }
 
 func TestDeleteQuotesHandler_InvalidID(t *testing.T) {
-    app := newTestAppQuotes()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodDelete, "/v1/quotes/", nil)
     rr := httptest.NewRecorder()
 
@@ -84,7 +79,7 @@ func TestDeleteQuotesHandler_InvalidID(t *testing.T) {
 }
 
 func TestListQuotesHandler_InvalidPageParam(t *testing.T) {
-    app := newTestAppQuotes()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodGet, "/v1/quotes?page=notint", nil)
     rr := httptest.NewRecorder()
 
@@ -93,4 +88,48 @@ func TestListQuotesHandler_InvalidPageParam(t *testing.T) {
     if rr.Code != http.StatusUnprocessableEntity {
         t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
     }
-}
\ No newline at end of file
+}
+func TestUpdateQuotesHandler_NotOwner(t *testing.T) {
+    // user 2's public quote, which user 1 can see but not change
+    db, _ := newFakeDB(func(query string, args []driver.Value) fakeResult {
+        row := []driver.Value{int64(5), int64(2), "bob", "Keep going", int64(0), "approved", "public", "", "", "", "{}", time.Now()}
+        return fakeResult{columns: make([]string, len(row)), rows: [][]driver.Value{row}}
+    })
+    defer db.Close()
+
+    app := newTestApp()
+    app.quoteModel = data.QuoteModel{DB: db}
+    req := httptest.NewRequest(http.MethodPatch, "/v1/quotes/5", bytes.NewBufferString(`{"visibility":"private"}`))
+    req = withIDParam(app.contextSetUser(req, &data.User{ID: 1}), "5")
+    rr := httptest.NewRecorder()
+
+    app.updateQuotesHandler(rr, req)
+
+    if rr.Code != http.StatusForbidden {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusForbidden, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDeleteQuotesHandler_NotOwner(t *testing.T) {
+    // the quote belongs to someone else, so nothing is deleted
+    db, fake := newFakeDB(func(query string, args []driver.Value) fakeResult {
+        return fakeResult{}
+    })
+    defer db.Close()
+
+    app := newTestApp()
+    app.quoteModel = data.QuoteModel{DB: db}
+    req := httptest.NewRequest(http.MethodDelete, "/v1/quotes/5", nil)
+    req = withIDParam(app.contextSetUser(req, &data.User{ID: 1}), "5")
+    rr := httptest.NewRecorder()
+
+    app.deleteQuotesHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+    deleted := fake.index("DELETE FROM quotes")
+    if deleted == -1 || len(fake.statements[deleted].args) != 2 || fake.statements[deleted].args[1] != int64(1) {
+        t.Fatalf("delete was not limited to the user's own quotes: %+v", fake.statements)
+    }
+}
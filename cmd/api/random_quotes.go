@@ -0,0 +1,79 @@
+// Filename: cmd/api/random_quotes.go
+package main
+
+import (
+	"net/http"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// GET /v1/quotes/random?count=&tag=&author=
+// Up to count random quotes (1 by default) that the user hasn't hidden,
+// optionally only those with the tags or by the author
+func (app *application) randomQuotesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	count := app.getSingleIntegerParameter(queryParameters, "count", 1, v)
+	data.ValidateRandomCount(v, count)
+	search := data.QuoteSearch{
+		Tags:   data.NormalizeTags(app.getMultipleQueryParameters(queryParameters, "tag", []string{})),
+		Author: app.getSingleQueryParameter(queryParameters, "author", ""),
+	}
+	data.ValidateQuoteSearch(v, search)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	quotes, err := app.quoteModel.Random(user.ID, count, search)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	err = app.writeJSON(w, http.StatusOK, envelope{"quotes": quotes}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// POST /v1/quotes/:id/hide
+func (app *application) hideQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	app.setQuoteHidden(w, r, true)
+}
+
+// DELETE /v1/quotes/:id/hide
+func (app *application) unhideQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	app.setQuoteHidden(w, r, false)
+}
+
+func (app *application) setQuoteHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	user := app.contextGetUser(r)
+
+	quote, ok := app.getVisibleQuote(w, r)
+	if !ok {
+		return
+	}
+	id := quote.ID
+
+	var err error
+
+	if hidden {
+		err = app.quoteModel.Hide(user.ID, id)
+	} else {
+		err = app.quoteModel.Unhide(user.ID, id)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"quote_id": id, "hidden": hidden}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
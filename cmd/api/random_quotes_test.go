@@ -0,0 +1,76 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestRandomQuotesHandler_InvalidCount(t *testing.T) {
+	for _, count := range []string{"0", "21", "many"} {
+		t.Run(count, func(t *testing.T) {
+			app := newTestApp()
+			req := httptest.NewRequest(http.MethodGet, "/v1/quotes/random?count="+count, nil)
+			req = app.contextSetUser(req, &data.User{ID: 1})
+			rr := httptest.NewRecorder()
+
+			app.randomQuotesHandler(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHideQuoteHandler_InvalidID(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodPost, "/v1/quotes/abc/hide", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.hideQuoteHandler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
+
+func TestRandomQuotesHandler_Sampling(t *testing.T) {
+	tests := map[string]struct {
+		query  string
+		probes bool
+	}{
+		"no filter": {"count=3", true},
+		"tag":       {"count=3&tag=focus", false},
+		"author":    {"count=3&author=Seneca", false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, fake := newFakeDB(func(query string, args []driver.Value) fakeResult {
+				return fakeResult{columns: make([]string, 12)}
+			})
+			defer db.Close()
+
+			app := newTestApp()
+			app.quoteModel = data.QuoteModel{DB: db}
+			req := httptest.NewRequest(http.MethodGet, "/v1/quotes/random?"+tt.query, nil)
+			req = app.contextSetUser(req, &data.User{ID: 1})
+			rr := httptest.NewRecorder()
+
+			app.randomQuotesHandler(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+			}
+			// a filter would skew the probes towards matches after long
+			// runs of other quotes, so the matches are shuffled instead
+			if probed := fake.index("probes AS") != -1; probed != tt.probes {
+				t.Errorf("probed the ID range = %t; want %t", probed, tt.probes)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Filename: cmd/api/reminders.go
+package main
+
+import (
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/i18n"
+)
+
+// The most reminders sent in one run. Anything left over goes out on the
+// next run
+const reminderBatchSize = 100
+
+// startReminders starts the goal and session reminder and weekly digest
+// schedulers
+func (app *application) startReminders(done <-chan struct{}) {
+	if !app.config.reminders.enabled {
+		return
+	}
+
+	app.schedule(done, app.config.reminders.interval, app.sendGoalReminders)
+	app.schedule(done, app.config.reminders.sessionInterval, app.sendSessionReminders)
+	app.schedule(done, app.config.reminders.digestInterval, app.sendWeeklyDigests)
+}
+
+// schedule runs job straight away and then every interval, until done is
+// closed. It runs as a background task so a graceful shutdown waits for the
+// current run to finish. The job gets done so it can stop early
+func (app *application) schedule(done <-chan struct{}, interval time.Duration, job func(done <-chan struct{})) {
+	if interval <= 0 {
+		return
+	}
+
+	app.background(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			job(done)
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// stopping reports whether done has been closed
+func stopping(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendGoalReminders emails the owners of goals that are nearly due or
+// overdue. Each reminder is claimed before it is sent so it goes out once
+func (app *application) sendGoalReminders(done <-chan struct{}) {
+	reminders, err := app.reminderModel.DueGoalReminders(app.config.reminders.goalWindowDays, reminderBatchSize)
+	if err != nil {
+		app.logger.Error("finding goal reminders", "error", err.Error())
+		return
+	}
+
+	sent := 0
+	for _, reminder := range reminders {
+		if stopping(done) {
+			return
+		}
+
+		claimed, err := app.reminderModel.ClaimGoalReminder(reminder)
+		if err != nil {
+			app.logger.Error("claiming goal reminder", "goal_id", reminder.GoalID, "error", err.Error())
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		templateFile := "goal_due_soon.tmpl"
+		if reminder.Kind == data.ReminderOverdue {
+			templateFile = "goal_overdue.tmpl"
+		}
+
+		err = app.notifier.Notify(Notification{
+			UserID:   reminder.UserID,
+			Email:    reminder.Email,
+			Template: templateFile,
+			Locale:   reminder.Locale,
+			Category: data.CategoryReminders,
+			Data: map[string]any{
+				"username":   reminder.Username,
+				"goalID":     reminder.GoalID,
+				"goalText":   reminder.GoalText,
+				"targetDate": reminder.TargetDate,
+				"daysLeft":   reminder.DaysLeft,
+				"daysLate":   -reminder.DaysLeft,
+			},
+		})
+		if err != nil {
+			app.logger.Error("sending goal reminder", "goal_id", reminder.GoalID, "error", err.Error())
+			// let the next run try again
+			if err := app.reminderModel.ReleaseGoalReminder(reminder); err != nil {
+				app.logger.Error("releasing goal reminder", "goal_id", reminder.GoalID, "error", err.Error())
+			}
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		app.logger.Info("sent goal reminders", "count", sent)
+	}
+}
+
+// sendSessionReminders notifies users of their upcoming study sessions. The
+// due offsets are claimed before sending, so a reminder goes out once even
+// across restarts or several running instances
+func (app *application) sendSessionReminders(done <-chan struct{}) {
+	reminders, err := app.reminderModel.DueSessionReminders(reminderBatchSize)
+	if err != nil {
+		app.logger.Error("finding session reminders", "error", err.Error())
+		return
+	}
+
+	sent := 0
+	for _, reminder := range reminders {
+		if stopping(done) {
+			return
+		}
+
+		claimed, err := app.reminderModel.ClaimSessionReminder(reminder)
+		if err != nil {
+			app.logger.Error("claiming session reminder", "session_id", reminder.SessionID, "error", err.Error())
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		// show the start time in the user's own time zone
+		loc := data.Preferences{TimeZone: reminder.TimeZone}.Location()
+		minutesLeft := max(1, int(time.Until(reminder.StartTime).Round(time.Minute).Minutes()))
+
+		err = app.notifier.Notify(Notification{
+			UserID:   reminder.UserID,
+			Email:    reminder.Email,
+			Template: "session_reminder.tmpl",
+			Locale:   reminder.Locale,
+			Category: data.CategoryReminders,
+			NotAfter: reminder.StartTime,
+			Data: map[string]any{
+				"username":    reminder.Username,
+				"sessionID":   reminder.SessionID,
+				"title":       reminder.Title,
+				"subject":     reminder.Subject,
+				"startTime":   i18n.FormatTime(reminder.Locale, reminder.StartTime.In(loc)),
+				"minutesLeft": minutesLeft,
+				"hoursLeft":   (minutesLeft + 30) / 60,
+			},
+		})
+		if err != nil {
+			app.logger.Error("sending session reminder", "session_id", reminder.SessionID, "error", err.Error())
+			if err := app.reminderModel.ReleaseSessionReminder(reminder); err != nil {
+				app.logger.Error("releasing session reminder", "session_id", reminder.SessionID, "error", err.Error())
+			}
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		app.logger.Info("sent session reminders", "count", sent)
+	}
+}
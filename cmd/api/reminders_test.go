@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestCreateStudySessionHandler_InvalidReminderOffsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		offsets string
+	}{
+		{"zero offset", `[0]`},
+		{"more than a week", `[10081]`},
+		{"too many reminders", `[5, 10, 15, 30, 60, 120]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp()
+			payload := `{"title":"Revision","start_time":"2025-12-01T09:00:00Z","end_time":"2025-12-01T10:00:00Z","reminder_offsets":` + tt.offsets + `}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/study-sessions", bytes.NewBufferString(payload))
+			req.Header.Set("Content-Type", "application/json")
+			usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+			req = app.contextSetUser(req, usr)
+			rr := httptest.NewRecorder()
+
+			app.createStudySessionHandler(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSchedule_StopsWhenDone(t *testing.T) {
+	app := newTestApp()
+	done := make(chan struct{})
+	runs := make(chan struct{}, 10)
+
+	app.schedule(done, time.Hour, func(done <-chan struct{}) {
+		runs <- struct{}{}
+	})
+
+	// the job runs once straight away
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("expected the job to run on start")
+	}
+
+	close(done)
+	waited := make(chan struct{})
+	go func() {
+		app.wg.Wait()
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("expected the scheduler to stop once done was closed")
+	}
+}
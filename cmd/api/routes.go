@@ -32,13 +32,49 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPatch, "/v1/users/update-password/:id", app.requirePermission("users:write", app.requireActivatedUser(app.updatePasswordHandler)))
 	router.HandlerFunc(http.MethodGet, "/v1/users/accounts", app.requirePermission("users:read", app.requireActivatedUser(app.listUsersHandler)))
 	router.HandlerFunc(http.MethodDelete, "/v1/users/delete/:id", app.requirePermission("users:write", app.requireActivatedUser(app.deleteUserHandler)))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/preferences", app.requireActivatedUser(app.showPreferencesHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/users/me/preferences", app.requireActivatedUser(app.updatePreferencesHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/notifications", app.requireActivatedUser(app.showNotificationPreferencesHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/users/me/notifications", app.requireActivatedUser(app.updateNotificationPreferencesHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/unsubscribe", app.confirmUnsubscribeHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/unsubscribe", app.unsubscribeHandler)
 
 	// Quotes
 	router.HandlerFunc(http.MethodPost, "/v1/quotes", app.requirePermission("quotes:write", app.requireActivatedUser(app.createQuotesHandler)))
-	router.HandlerFunc(http.MethodGet, "/v1/quotes/:id", app.requirePermission("quotes:read", app.requireActivatedUser(app.displayQuotesHandler)))
+	router.HandlerFunc(http.MethodGet, "/v1/quotes/:id", app.paramOrFixed("id", app.requirePermission("quotes:read", app.requireActivatedUser(app.displayQuotesHandler)), map[string]http.HandlerFunc{
+		"daily":  app.requirePermission("quotes:read", app.requireActivatedUser(app.dailyQuoteHandler)),
+		"random": app.requirePermission("quotes:read", app.requireActivatedUser(app.randomQuotesHandler)),
+		"tags":   app.requirePermission("quotes:read", app.requireActivatedUser(app.listQuoteTagsHandler)),
+		"export": app.requirePermission("quotes:read", app.requireActivatedUser(app.exportQuotesHandler)),
+		// open to visitors who aren't signed in
+		"public": app.publicQuotesHandler,
+	}))
 	router.HandlerFunc(http.MethodGet, "/v1/quotes", app.requirePermission("quotes:read", app.requireActivatedUser(app.listQuotesHandler)))
+	// POST /v1/quotes/:id is only there to route /v1/quotes/import past the
+	// /v1/quotes/:id/... routes
+	router.HandlerFunc(http.MethodPost, "/v1/quotes/:id", app.paramOrFixed("id", app.methodNotAllowedResponse, map[string]http.HandlerFunc{
+		"import": app.requirePermission("quotes:write", app.requireActivatedUser(app.importQuotesHandler)),
+	}))
 	router.HandlerFunc(http.MethodPatch, "/v1/quotes/:id", app.requirePermission("quotes:write", app.requireActivatedUser(app.updateQuotesHandler)))
 	router.HandlerFunc(http.MethodDelete, "/v1/quotes/:id", app.requirePermission("quotes:write", app.requireActivatedUser(app.deleteQuotesHandler)))
+	router.HandlerFunc(http.MethodPost, "/v1/quotes/:id/hide", app.requirePermission("quotes:read", app.requireActivatedUser(app.hideQuoteHandler)))
+	router.HandlerFunc(http.MethodDelete, "/v1/quotes/:id/hide", app.requirePermission("quotes:read", app.requireActivatedUser(app.unhideQuoteHandler)))
+	router.HandlerFunc(http.MethodPost, "/v1/quotes/:id/favorite", app.requirePermission("quotes:read", app.requireActivatedUser(app.favoriteQuoteHandler)))
+	router.HandlerFunc(http.MethodDelete, "/v1/quotes/:id/favorite", app.requirePermission("quotes:read", app.requireActivatedUser(app.unfavoriteQuoteHandler)))
+	router.HandlerFunc(http.MethodPost, "/v1/quotes/:id/report", app.requirePermission("quotes:read", app.requireActivatedUser(app.reportQuoteHandler)))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/favorites", app.requirePermission("quotes:read", app.requireActivatedUser(app.listFavoritesHandler)))
+
+	// Follows
+	router.HandlerFunc(http.MethodPost, "/v1/follows/:id", app.requireActivatedUser(app.followUserHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/follows/:id", app.requireActivatedUser(app.unfollowUserHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/followers", app.requireActivatedUser(app.listFollowersHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/users/me/followers/:id", app.requireActivatedUser(app.approveFollowerHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/me/followers/:id", app.requireActivatedUser(app.removeFollowerHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/following", app.requireActivatedUser(app.listFollowingHandler))
+
+	// Moderation
+	router.HandlerFunc(http.MethodGet, "/v1/moderation/quotes", app.requirePermission(moderatePermission, app.requireActivatedUser(app.listModerationQueueHandler)))
+	router.HandlerFunc(http.MethodPatch, "/v1/moderation/quotes/:id", app.requirePermission(moderatePermission, app.requireActivatedUser(app.moderateQuoteHandler)))
 
 	// Goals
 	router.HandlerFunc(http.MethodPost, "/v1/goals", app.requirePermission("goals:write", app.requireActivatedUser(app.createGoalsHandler)))
@@ -46,16 +82,59 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodGet, "/v1/goals", app.requirePermission("goals:read", app.requireActivatedUser(app.listGoalsHandler)))
 	router.HandlerFunc(http.MethodPatch, "/v1/goals/:id", app.requirePermission("goals:write", app.requireActivatedUser(app.updateGoalsHandler)))
 	router.HandlerFunc(http.MethodDelete, "/v1/goals/:id", app.requirePermission("goals:write", app.requireActivatedUser(app.deleteGoalsHandler)))
+	router.HandlerFunc(http.MethodGet, "/v1/goals/:id/milestones", app.requirePermission("goals:read", app.requireActivatedUser(app.listMilestonesHandler)))
+	router.HandlerFunc(http.MethodPost, "/v1/goals/:id/milestones", app.requirePermission("goals:write", app.requireActivatedUser(app.createMilestoneHandler)))
+	router.HandlerFunc(http.MethodPatch, "/v1/goals/:id/milestones/:milestone_id", app.requirePermission("goals:write", app.requireActivatedUser(app.updateMilestoneHandler)))
+	router.HandlerFunc(http.MethodDelete, "/v1/goals/:id/milestones/:milestone_id", app.requirePermission("goals:write", app.requireActivatedUser(app.deleteMilestoneHandler)))
+	router.HandlerFunc(http.MethodPost, "/v1/goals/:id/checkins", app.requirePermission("goals:write", app.requireActivatedUser(app.createCheckinHandler)))
+	router.HandlerFunc(http.MethodGet, "/v1/goals/:id/history", app.requirePermission("goals:read", app.requireActivatedUser(app.goalHistoryHandler)))
 
 	// Study Sessions
 	router.HandlerFunc(http.MethodPost, "/v1/study-sessions", app.requirePermission("study_sessions:write", app.requireActivatedUser(app.createStudySessionHandler)))
-	router.HandlerFunc(http.MethodGet, "/v1/study-sessions/:id", app.requirePermission("study_sessions:read", app.requireActivatedUser(app.displayStudySessionHandler)))
+	router.HandlerFunc(http.MethodGet, "/v1/study-sessions/:id", app.paramOrFixed("id", app.requirePermission("study_sessions:read", app.requireActivatedUser(app.displayStudySessionHandler)), map[string]http.HandlerFunc{
+		"suggestions": app.requirePermission("study_sessions:read", app.requireActivatedUser(app.sessionSuggestionsHandler)),
+	}))
 	router.HandlerFunc(http.MethodGet, "/v1/study-sessions", app.requirePermission("study_sessions:read", app.requireActivatedUser(app.listStudySessionsHandler)))
 	router.HandlerFunc(http.MethodPatch, "/v1/study-sessions/:id", app.requirePermission("study_sessions:write", app.requireActivatedUser(app.updateStudySessionHandler)))
 	router.HandlerFunc(http.MethodDelete, "/v1/study-sessions/:id", app.requirePermission("study_sessions:write", app.requireActivatedUser(app.deleteStudySessionHandler)))
 
+	// Search
+	router.HandlerFunc(http.MethodGet, "/v1/search", app.requireActivatedUser(app.searchHandler))
+
+	// Stats
+	router.HandlerFunc(http.MethodGet, "/v1/stats/study", app.requirePermission("study_sessions:read", app.requireActivatedUser(app.studyStatsHandler)))
+	router.HandlerFunc(http.MethodGet, "/v1/stats/streaks", app.requirePermission("study_sessions:read", app.requireActivatedUser(app.studyStreaksHandler)))
+
+	// In-app notifications
+	router.HandlerFunc(http.MethodGet, "/v1/notifications", app.requireActivatedUser(app.listNotificationsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/notifications/unread-count", app.requireActivatedUser(app.unreadNotificationsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/notifications/read", app.requireActivatedUser(app.markNotificationsReadHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/notifications/read-all", app.requireActivatedUser(app.markAllNotificationsReadHandler))
+
+	// Real-time updates
+	router.HandlerFunc(http.MethodGet, "/v1/events", app.requireActivatedUser(app.eventsHandler))
+
+	// Background jobs
+	router.HandlerFunc(http.MethodGet, "/v1/jobs", app.requirePermission("jobs:read", app.requireActivatedUser(app.listJobsHandler)))
+	router.HandlerFunc(http.MethodGet, "/v1/jobs/:id", app.requirePermission("jobs:read", app.requireActivatedUser(app.showJobHandler)))
+
 	// Metrics endpoint
 	router.Handler(http.MethodGet, "/v1/observability/course/metrics", expvar.Handler())
 
 	return app.metrics(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(router)))))
 }
+
+// paramOrFixed serves fixed paths like /v1/quotes/daily that share their
+// last segment with a parameter like /v1/quotes/:id, since httprouter can't
+// register both. A request goes to the fixed path's handler when the
+// parameter matches its name, and to next otherwise
+func (app *application) paramOrFixed(param string, next http.HandlerFunc, fixed map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := httprouter.ParamsFromContext(r.Context())
+		if handler, ok := fixed[params.ByName(param)]; ok {
+			handler(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
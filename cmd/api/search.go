@@ -0,0 +1,71 @@
+// Filename: cmd/api/search.go
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// The permission needed to search each type
+var searchPermissions = map[string]string{
+	data.SearchSessions: "study_sessions:read",
+	data.SearchGoals:    "goals:read",
+	data.SearchQuotes:   "quotes:read",
+}
+
+// GET /v1/search?q=&type=&limit=
+// Searches the user's sessions and goals, and the quotes they can see, and
+// returns the best matches of each type with the matching words marked.
+// q takes web search syntax like "exam prep" -chemistry, type=goals,quotes
+// narrows down what's searched and limit is how many of each to return.
+// Types the user isn't permitted to read are left out unless asked for
+func (app *application) searchHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	query := strings.TrimSpace(app.getSingleQueryParameter(queryParameters, "q", ""))
+	types := data.NormalizeTags(app.getMultipleQueryParameters(queryParameters, "type", []string{}))
+	limit := app.getSingleIntegerParameter(queryParameters, "limit", 10, v)
+	data.ValidateSearch(v, query, types, limit)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	permissions, err := app.permissionModel.GetAllForUser(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if len(types) == 0 {
+		for _, t := range data.SearchTypes {
+			if permissions.Include(searchPermissions[t]) {
+				types = append(types, t)
+			}
+		}
+	}
+	for _, t := range types {
+		if !permissions.Include(searchPermissions[t]) {
+			app.notPermittedResponse(w, r)
+			return
+		}
+	}
+
+	results := envelope{}
+	for _, t := range types {
+		results[t], err = app.searchModel.Search(t, user.ID, user.Locale, query, limit)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"query": query, "results": results}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
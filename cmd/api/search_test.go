@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestSearchHandler_InvalidParams(t *testing.T) {
+	tests := map[string]struct {
+		url, field string
+	}{
+		"missing query":  {"/v1/search", `"q"`},
+		"blank query":    {"/v1/search?q=%20%20", `"q"`},
+		"unknown type":   {"/v1/search?q=exam&type=goals,notes", `"type"`},
+		"limit too high": {"/v1/search?q=exam&limit=51", `"limit"`},
+		"limit not int":  {"/v1/search?q=exam&limit=ten", `"limit"`},
+	}
+	for name, tt := range tests {
+		app := newTestApp()
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		req = app.contextSetUser(req, &data.User{ID: 1})
+		rr := httptest.NewRecorder()
+
+		app.searchHandler(rr, req)
+
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected status %d; got %d; body=%s", name, http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			continue
+		}
+		if !strings.Contains(rr.Body.String(), tt.field) {
+			t.Errorf("%s: expected an error for %s; body=%s", name, tt.field, rr.Body.String())
+		}
+	}
+}
@@ -24,9 +24,13 @@ func (app *application) serve() error {
 		WriteTimeout: 10 * time.Second,
 		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
 	}
+	// event streams never finish on their own, so end them on shutdown
+	srv.RegisterOnShutdown(app.events.close)
 
 	// create a channel to keep track of any errors during the shutdown process
 	shutdownError := make(chan error)
+	// closed on shutdown to stop the scheduled background jobs
+	done := make(chan struct{})
 	// create a goroutine that runs in the background listening
 	// for the shutdown signals
 	go func() {
@@ -44,8 +48,9 @@ func (app *application) serve() error {
 		if err != nil {
 			shutdownError <- err
 		}
-		// Wait for background tasks to complete
+		// Stop the scheduled jobs and wait for background tasks to complete
 		app.logger.Info("completing background tasks", "address", srv.Addr)
+		close(done)
 		app.wg.Wait()
 		shutdownError <- nil // successful shutdown
 	}()
@@ -53,6 +58,10 @@ func (app *application) serve() error {
 	app.logger.Info("starting server", "address", srv.Addr,
 		"environment", app.config.env)
 
+	app.startJobWorkers(done)
+	app.startReminders(done)
+	app.startEvents(done)
+
 	err := srv.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestListStudySessionsHandler_InvalidFilters(t *testing.T) {
+	tests := map[string]struct {
+		query, field string
+	}{
+		"bad from":          {"from=2025-13-01", `"from"`},
+		"bad to":            {"to=yesterday", `"to"`},
+		"from after to":     {"from=2025-03-10&to=2025-03-01", `"from"`},
+		"negative minimum":  {"min_minutes=-5", `"min_minutes"`},
+		"max below min":     {"min_minutes=60&max_minutes=30", `"max_minutes"`},
+		"duration not int":  {"max_minutes=long", `"max_minutes"`},
+		"minimum too long":  {"min_minutes=10081", `"min_minutes"`},
+		"maximum too long":  {"max_minutes=3000000000", `"max_minutes"`},
+		"unknown status":    {"status=cancelled", `"status"`},
+		"unknown sort":      {"sort=-end_time", `"sort"`},
+		"too many subjects": {"subject=a,b,c,d,e,f,g,h,i,j,k", `"subject"`},
+	}
+	for name, tt := range tests {
+		app := newTestApp()
+		req := httptest.NewRequest(http.MethodGet, "/v1/study-sessions?"+tt.query, nil)
+		req = app.contextSetUser(req, &data.User{ID: 1})
+		rr := httptest.NewRecorder()
+
+		app.listStudySessionsHandler(rr, req)
+
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected status %d; got %d; body=%s", name, http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			continue
+		}
+		if !strings.Contains(rr.Body.String(), tt.field) {
+			t.Errorf("%s: expected an error for %s; body=%s", name, tt.field, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Filename: cmd/api/session_suggestions.go
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// GET /v1/study-sessions/suggestions?field=subject&q=calc&limit=10
+// Autocompletes a session's subject, or its title with field=title, from
+// the user's own sessions. Partial words and small typos still match
+func (app *application) sessionSuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	field := app.getSingleQueryParameter(queryParameters, "field", data.SuggestSubject)
+	prefix := strings.TrimSpace(app.getSingleQueryParameter(queryParameters, "q", ""))
+	limit := app.getSingleIntegerParameter(queryParameters, "limit", 10, v)
+	data.ValidateSuggestions(v, field, prefix, limit)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	suggestions, err := app.studysessionModel.Suggest(user.ID, field, prefix, limit)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"suggestions": suggestions}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestSessionSuggestionsHandler_InvalidParams(t *testing.T) {
+	tests := map[string]struct {
+		url, field string
+	}{
+		"unknown field":  {"/v1/study-sessions/suggestions?field=description&q=calc", `"field"`},
+		"long query":     {"/v1/study-sessions/suggestions?q=" + strings.Repeat("a", 101), `"q"`},
+		"limit too high": {"/v1/study-sessions/suggestions?q=calc&limit=21", `"limit"`},
+		"limit zero":     {"/v1/study-sessions/suggestions?q=calc&limit=0", `"limit"`},
+	}
+	for name, tt := range tests {
+		app := newTestApp()
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		req = app.contextSetUser(req, &data.User{ID: 1})
+		rr := httptest.NewRecorder()
+
+		app.sessionSuggestionsHandler(rr, req)
+
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected status %d; got %d; body=%s", name, http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			continue
+		}
+		if !strings.Contains(rr.Body.String(), tt.field) {
+			t.Errorf("%s: expected an error for %s; body=%s", name, tt.field, rr.Body.String())
+		}
+	}
+}
+
+func TestSessionSuggestionsHandler_SetsFuzzyThreshold(t *testing.T) {
+	db, fake := newFakeDB(func(query string, args []driver.Value) fakeResult {
+		if strings.Contains(query, "set_config") {
+			return fakeResult{columns: []string{"set_config"}, rows: [][]driver.Value{{args[0]}}}
+		}
+		return fakeResult{columns: []string{"value", "count"}, rows: [][]driver.Value{{"Calculus", int64(3)}}}
+	})
+	defer db.Close()
+
+	app := newTestApp()
+	app.studysessionModel = data.StudySessionModel{DB: db}
+	req := httptest.NewRequest(http.MethodGet, "/v1/study-sessions/suggestions?q=calclus", nil)
+	req = app.contextSetUser(req, &data.User{ID: 1})
+	rr := httptest.NewRecorder()
+
+	app.sessionSuggestionsHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	// the database's own threshold is left alone, so the search sets it
+	set := fake.index("pg_trgm.word_similarity_threshold")
+	searched := fake.index("word_similarity(")
+	if set == -1 || searched == -1 || set > searched {
+		t.Fatalf("set the threshold at statement %d and searched at %d; want it set first", set, searched)
+	}
+	if threshold := fake.statements[set].args[0]; threshold != "0.4" {
+		t.Errorf("threshold = %v; want 0.4", threshold)
+	}
+}
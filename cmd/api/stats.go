@@ -0,0 +1,96 @@
+// Filename: cmd/api/stats.go
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// Study analytics for the logged-in user over a date range
+func (app *application) studyStatsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	if user.IsAnonymous() {
+		app.authenticationRequiredResponse(w, r)
+		return
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	tz := app.getSingleQueryParameter(queryParameters, "tz", user.Location().String())
+	data.ValidateTimeZone(v, "tz", tz)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	// default to the last 30 days, ending today in the user's time zone
+	loc, _ := time.LoadLocation(tz)
+	today := localToday(loc)
+
+	from := today.AddDate(0, 0, -29)
+	to := today
+	if fromStr := app.getSingleQueryParameter(queryParameters, "from", ""); fromStr != "" {
+		from = parseDate(fromStr)
+	}
+	if toStr := app.getSingleQueryParameter(queryParameters, "to", ""); toStr != "" {
+		to = parseDate(toStr)
+	}
+
+	data.ValidateStatsRange(v, from, to)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	stats, err := app.statsModel.StudyForUser(user.ID, from, to, tz, user.FirstDayOfWeek)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"stats": stats}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Current and longest study streaks plus a year of per-day minutes for the
+// activity heatmap
+func (app *application) studyStreaksHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+	if user.IsAnonymous() {
+		app.authenticationRequiredResponse(w, r)
+		return
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	tz := app.getSingleQueryParameter(queryParameters, "tz", user.Location().String())
+	data.ValidateTimeZone(v, "tz", tz)
+	minMinutes := app.getSingleIntegerParameter(queryParameters, "min_minutes", app.config.stats.streakMinMinutes, v)
+	v.Check(minMinutes >= 1, "min_minutes", "min_value", 1)
+	v.Check(minMinutes <= 24*60, "min_minutes", "max_value", 1440)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	loc, _ := time.LoadLocation(tz)
+	today := localToday(loc)
+
+	streaks, err := app.statsModel.StreaksForUser(user.ID, today, tz, minMinutes)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"streaks": streaks}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
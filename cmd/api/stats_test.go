@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func TestStudyStatsHandler_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"unknown time zone", "/v1/stats/study?tz=Mars/Olympus"},
+		{"local time zone", "/v1/stats/study?tz=Local"},
+		{"bad from date", "/v1/stats/study?from=2025-13-01"},
+		{"from after to", "/v1/stats/study?from=2025-11-10&to=2025-11-01"},
+		{"range too long", "/v1/stats/study?from=2024-01-01&to=2025-11-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp()
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+			req = app.contextSetUser(req, usr)
+			rr := httptest.NewRecorder()
+
+			app.studyStatsHandler(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestStudyStatsHandler_Anonymous(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest(http.MethodGet, "/v1/stats/study", nil)
+	req = app.contextSetUser(req, data.AnonymousUser)
+	rr := httptest.NewRecorder()
+
+	app.studyStatsHandler(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnauthorized, rr.Code, rr.Body.String())
+	}
+}
+
+func TestStudyStreaksHandler_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"unknown time zone", "/v1/stats/streaks?tz=Nowhere"},
+		{"non-integer threshold", "/v1/stats/streaks?min_minutes=abc"},
+		{"zero threshold", "/v1/stats/streaks?min_minutes=0"},
+		{"threshold over a day", "/v1/stats/streaks?min_minutes=1441"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp()
+			app.config.stats.streakMinMinutes = 1
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+			req = app.contextSetUser(req, usr)
+			rr := httptest.NewRecorder()
+
+			app.studyStreaksHandler(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/aiycoleman/Study-Mate/internal/data"
@@ -20,12 +21,14 @@ func (app *application) createStudySessionHandler(w http.ResponseWriter, r *http
 	}
 
 	var incomingData struct {
-		Title       string    `json:"title"`
-		Description string    `json:"description"`
-		Subject     string    `json:"subject"`
-		StartTime   time.Time `json:"start_time"`
-		EndTime     time.Time `json:"end_time"`
-		IsCompleted bool      `json:"is_completed"`
+		Title           string    `json:"title"`
+		Description     string    `json:"description"`
+		Subject         string    `json:"subject"`
+		StartTime       time.Time `json:"start_time"`
+		EndTime         time.Time `json:"end_time"`
+		Tags            []string  `json:"tags"`
+		IsCompleted     bool      `json:"is_completed"`
+		ReminderOffsets []int64   `json:"reminder_offsets"` // minutes before start_time, e.g. [10, 60]
 	}
 
 	err := app.readJSON(w, r, &incomingData)
@@ -35,20 +38,22 @@ func (app *application) createStudySessionHandler(w http.ResponseWriter, r *http
 	}
 
 	studySession := &data.StudySession{
-		UserID:      user.ID,
-		Title:       incomingData.Title,
-		Description: incomingData.Description,
-		Subject:     incomingData.Subject,
-		StartTime:   incomingData.StartTime,
-		EndTime:     incomingData.EndTime,
-		IsCompleted: incomingData.IsCompleted,
+		UserID:          user.ID,
+		Title:           incomingData.Title,
+		Description:     incomingData.Description,
+		Subject:         incomingData.Subject,
+		StartTime:       incomingData.StartTime,
+		EndTime:         incomingData.EndTime,
+		Tags:            data.NormalizeTags(incomingData.Tags),
+		IsCompleted:     incomingData.IsCompleted,
+		ReminderOffsets: data.NormalizeReminderOffsets(incomingData.ReminderOffsets),
 	}
 
 	// Validate the study session data
 	v := validator.New()
 	data.ValidateStudySession(v, studySession)
 	if !v.Valid() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -59,6 +64,13 @@ func (app *application) createStudySessionHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	// a completed session may push a measurable goal over its target
+	_, err = app.goalModel.CompleteReached(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/study-sessions/%d", studySession.ID))
 
@@ -110,10 +122,7 @@ func (app *application) listStudySessionsHandler(w http.ResponseWriter, r *http.
 	}
 	
 	var queryParametersData struct {
-		Title       string
-		Description string
-		Subject     string
-		IsCompleted *bool
+		data.SessionSearch
 		data.Filters
 	}
 
@@ -122,7 +131,12 @@ func (app *application) listStudySessionsHandler(w http.ResponseWriter, r *http.
 
 	// load the query parameters into the struct
 	queryParametersData.Title = app.getSingleQueryParameter(queryParameters, "title", "")
-	queryParametersData.Subject = app.getSingleQueryParameter(queryParameters, "subject", "")
+	// subject=math,physics keeps sessions in either subject
+	for _, subject := range app.getMultipleQueryParameters(queryParameters, "subject", []string{}) {
+		if subject = strings.TrimSpace(subject); subject != "" {
+			queryParametersData.Subjects = append(queryParametersData.Subjects, subject)
+		}
+	}
 
 	isCompletedStr := app.getSingleQueryParameter(queryParameters, "is_completed", "")
 	if isCompletedStr != "" {
@@ -131,20 +145,36 @@ func (app *application) listStudySessionsHandler(w http.ResponseWriter, r *http.
 	}
 
 	v := validator.New()
+
+	// from and to are days in the user's time zone, and either can be left out
+	queryParametersData.TimeZone = user.Location().String()
+	if fromStr := app.getSingleQueryParameter(queryParameters, "from", ""); fromStr != "" {
+		queryParametersData.From = parseDate(fromStr)
+		v.Check(!queryParametersData.From.IsZero(), "from", "invalid_date")
+	}
+	if toStr := app.getSingleQueryParameter(queryParameters, "to", ""); toStr != "" {
+		queryParametersData.To = parseDate(toStr)
+		v.Check(!queryParametersData.To.IsZero(), "to", "invalid_date")
+	}
+	queryParametersData.MinMinutes = app.getSingleIntegerParameter(queryParameters, "min_minutes", 0, v)
+	queryParametersData.MaxMinutes = app.getSingleIntegerParameter(queryParameters, "max_minutes", 0, v)
+	queryParametersData.Status = app.getSingleQueryParameter(queryParameters, "status", "")
+
 	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
 	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 20, v)
 	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "created_at")
-	queryParametersData.Filters.SortSafeList = []string{"session_id", "title", "subject", "is_completed", "created_at"}
+	queryParametersData.Filters.SortSafeList = []string{"session_id", "title", "subject", "is_completed", "created_at", "start_time", "-start_time", "duration", "-duration"}
 
 	// Validate the filters
+	data.ValidateSessionSearch(v, queryParametersData.SessionSearch)
 	data.ValidateFilters(v, queryParametersData.Filters)
 	if !v.Valid() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
 	// Get the study sessions from the database - FILTER BY USER ID
-	studySessions, metadata, err := app.studysessionModel.GetAllForUser(user.ID, queryParametersData.Title, queryParametersData.Subject, queryParametersData.IsCompleted, queryParametersData.Filters)
+	studySessions, metadata, err := app.studysessionModel.GetAllForUser(user.ID, queryParametersData.SessionSearch, queryParametersData.Filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -183,12 +213,14 @@ func (app *application) updateStudySessionHandler(w http.ResponseWriter, r *http
 	}
 
 	var incomingData struct {
-		Title       *string    `json:"title"`
-		Description *string    `json:"description"`
-		Subject     *string    `json:"subject"`
-		StartTime   *time.Time `json:"start_time"`
-		EndTime     *time.Time `json:"end_time"`
-		IsCompleted *bool      `json:"is_completed"`
+		Title           *string    `json:"title"`
+		Description     *string    `json:"description"`
+		Subject         *string    `json:"subject"`
+		StartTime       *time.Time `json:"start_time"`
+		EndTime         *time.Time `json:"end_time"`
+		Tags            []string   `json:"tags"`
+		IsCompleted     *bool      `json:"is_completed"`
+		ReminderOffsets []int64    `json:"reminder_offsets"`
 	}
 
 	err = app.readJSON(w, r, &incomingData)
@@ -213,15 +245,21 @@ func (app *application) updateStudySessionHandler(w http.ResponseWriter, r *http
 	if incomingData.EndTime != nil {
 		studySession.EndTime = *incomingData.EndTime
 	}
+	if incomingData.Tags != nil {
+		studySession.Tags = data.NormalizeTags(incomingData.Tags)
+	}
 	if incomingData.IsCompleted != nil {
 		studySession.IsCompleted = *incomingData.IsCompleted
 	}
+	if incomingData.ReminderOffsets != nil {
+		studySession.ReminderOffsets = data.NormalizeReminderOffsets(incomingData.ReminderOffsets)
+	}
 
 	// Validate the updated study session data
 	v := validator.New()
 	data.ValidateStudySession(v, studySession)
 	if !v.IsEmpty() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -232,6 +270,12 @@ func (app *application) updateStudySessionHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	_, err = app.goalModel.CompleteReached(studySession.UserID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	// Send the updated study session as JSON response
 	data := envelope{"study_session": studySession}
 	err = app.writeJSON(w, http.StatusOK, data, nil)
@@ -5,18 +5,11 @@ import (
     "net/http"
     "net/http/httptest"
     "testing"
-    "io"
-    "log/slog"
     "github.com/aiycoleman/Study-Mate/internal/data"
 )
 
-func newTestAppSessions() *application {
-    logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-    return &application{logger: logger}
-}
-
 func TestCreateStudySessionHandler_BadJSON(t *testing.T) {
-    app := newTestAppSessions()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodPost, "/v1/study-sessions", bytes.NewBufferString("{bad json"))
     // set an authenticated user in context to avoid panic
     usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
@@ -31,7 +24,7 @@ func TestCreateStudySessionHandler_BadJSON(t *testing.T) {
 }
 
 func TestCreateStudySessionHandler_InvalidData(t *testing.T) {
-    app := newTestAppSessions()
+    app := newTestApp()
     // missing title should be invalid; omit time fields so they unmarshal as zero values
     payload := `{"title":"","description":"","subject":""}`
     req := httptest.NewRequest(http.MethodPost, "/v1/study-sessions", bytes.NewBufferString(payload))
@@ -49,7 +42,7 @@ func TestCreateStudySessionHandler_InvalidData(t *testing.T) {
 }
 
 func TestDisplayStudySessionHandler_InvalidID(t *testing.T) {
-    app := newTestAppSessions()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodGet, "/v1/study-sessions/", nil)
     rr := httptest.NewRecorder()
 
@@ -61,7 +54,7 @@ func TestDisplayStudySessionHandler_InvalidID(t *testing.T) {
 }
 
 func TestUpdateStudySessionHandler_InvalidID(t *testing.T) {
-    app := newTestAppSessions()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodPatch, "/v1/study-sessions/", nil)
     rr := httptest.NewRecorder()
 
@@ -73,7 +66,7 @@ func TestUpdateStudySessionHandler_InvalidID(t *testing.T) {
 }
 
 func TestDeleteStudySessionHandler_InvalidID(t *testing.T) {
-    app := newTestAppSessions()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodDelete, "/v1/study-sessions/", nil)
     rr := httptest.NewRecorder()
 
@@ -85,7 +78,7 @@ func TestDeleteStudySessionHandler_InvalidID(t *testing.T) {
 }
 
 func TestListStudySessionsHandler_InvalidPageParam(t *testing.T) {
-    app := newTestAppSessions()
+    app := newTestApp()
     req := httptest.NewRequest(http.MethodGet, "/v1/study-sessions?page=notint", nil)
     rr := httptest.NewRecorder()
 
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// newTestApp returns an application that discards its logs and has nothing
+// else set up. Each call gets its own, so tests can give it the models or
+// config they need
+func newTestApp() *application {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return &application{logger: logger}
+}
+
+// withIDParam sets the :id route parameter, as the router would
+func withIDParam(req *http.Request, id string) *http.Request {
+	params := httprouter.Params{{Key: "id", Value: id}}
+	return req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, params))
+}
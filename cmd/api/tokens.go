@@ -31,7 +31,7 @@ func (a *application) createAuthenticationTokenHandler(w http.ResponseWriter, r
 	data.ValidatePasswordPlaintext(v, incomingData.Password)
 
 	if !v.IsEmpty() {
-		a.failedValidationResponse(w, r, v.Errors)
+		a.failedValidationResponse(w, r, v)
 		return
 	}
 	// Is there an associated user for the provided email?
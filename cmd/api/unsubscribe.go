@@ -0,0 +1,169 @@
+// Filename: cmd/api/unsubscribe.go
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+	"github.com/aiycoleman/Study-Mate/internal/i18n"
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// Links sent before notification categories existed name the weekly digest
+const legacyWeeklyDigest = "weekly_digest"
+
+// unsubscribeToken is "<user id>.<category>.<signature>". The signature lets
+// the link work without logging in while making sure it can't be edited to
+// unsubscribe somebody else
+func (app *application) unsubscribeToken(userID int64, category string) string {
+	payload := strconv.FormatInt(userID, 10) + "." + category
+	return payload + "." + app.signUnsubscribe(payload)
+}
+
+func (app *application) signUnsubscribe(payload string) string {
+	mac := hmac.New(sha256.New, []byte(app.config.unsubscribe.secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseUnsubscribeToken checks the signature and returns the user and
+// category
+func (app *application) parseUnsubscribeToken(token string) (int64, string, bool) {
+	payload, signature, found := cutLast(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(app.signUnsubscribe(payload))) {
+		return 0, "", false
+	}
+
+	idStr, category, found := strings.Cut(payload, ".")
+	if !found {
+		return 0, "", false
+	}
+	userID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || userID < 1 {
+		return 0, "", false
+	}
+
+	if category == legacyWeeklyDigest {
+		category = data.CategoryDigests
+	}
+
+	return userID, category, true
+}
+
+// cutLast is strings.Cut around the last sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+// unsubscribeURL is the one-click link put in emails
+func (app *application) unsubscribeURL(userID int64, category string) string {
+	return strings.TrimSuffix(app.config.unsubscribe.baseURL, "/") + "/v1/unsubscribe?token=" + url.QueryEscape(app.unsubscribeToken(userID, category))
+}
+
+// confirmUnsubscribePage asks before unsubscribing, with a form that posts
+// back to the same link
+var confirmUnsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!doctype html>
+<html lang="{{.Lang}}">
+<head>
+    <meta name="viewport" content="width=device-width" />
+    <meta charset="UTF-8" />
+    <title>Study Mate</title>
+</head>
+<body>
+    <form method="post">
+        <p>{{.Question}}</p>
+        <button type="submit">{{.Button}}</button>
+    </form>
+</body>
+</html>
+`))
+
+// GET /v1/unsubscribe?token=
+// What clicking the link in an email does. It only asks for confirmation,
+// since mail scanners open links to check them
+func (app *application) confirmUnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	_, category, ok := app.readUnsubscribeToken(w, r)
+	if !ok {
+		return
+	}
+
+	lang := app.requestLanguage(r)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	err := confirmUnsubscribePage.Execute(w, map[string]string{
+		"Lang":     lang,
+		"Question": i18n.T(lang, "unsubscribe_"+category+"_question"),
+		"Button":   i18n.T(lang, "unsubscribe_button"),
+	})
+	if err != nil {
+		app.logger.Error("rendering unsubscribe page", "error", err.Error())
+	}
+}
+
+// POST /v1/unsubscribe?token=
+// Turn off a category of email with the signed token from an email. This is
+// what the confirmation page sends, and also the one-click unsubscribe that
+// mail clients send (RFC 8058)
+func (app *application) unsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, category, ok := app.readUnsubscribeToken(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.notificationPreferenceModel.SetEnabled(userID, data.ChannelEmail, category, false)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	message := i18n.T(app.requestLanguage(r), "unsubscribed_"+category)
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": message}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readUnsubscribeToken gets the user and category from the token in the
+// query string. When it is invalid, or for a category that can't be turned
+// off, it sends the error response and returns false
+func (app *application) readUnsubscribeToken(w http.ResponseWriter, r *http.Request) (int64, string, bool) {
+	token := r.URL.Query().Get("token")
+
+	v := validator.New()
+	v.Check(token != "", "token", "required")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return 0, "", false
+	}
+
+	userID, category, ok := app.parseUnsubscribeToken(token)
+	if !ok {
+		v.AddError("token", "invalid_unsubscribe_token")
+		app.failedValidationResponse(w, r, v)
+		return 0, "", false
+	}
+
+	switch {
+	case category == data.CategorySecurity:
+		v.AddError("token", "mandatory_notification")
+		app.failedValidationResponse(w, r, v)
+		return 0, "", false
+	case !validator.PermittedValue(category, data.NotificationCategories...):
+		// signed by us, but for a category that no longer exists
+		app.notFoundResponse(w, r)
+		return 0, "", false
+	}
+
+	return userID, category, true
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aiycoleman/Study-Mate/internal/data"
+)
+
+func newTestAppUnsubscribe() *application {
+	app := newTestApp()
+	app.config.unsubscribe.secret = "test-secret"
+	app.config.unsubscribe.baseURL = "https://api.example.com/"
+	return app
+}
+
+func TestUnsubscribeToken_RoundTrip(t *testing.T) {
+	app := newTestAppUnsubscribe()
+
+	token := app.unsubscribeToken(42, data.CategoryDigests)
+	userID, category, ok := app.parseUnsubscribeToken(token)
+	if !ok || userID != 42 || category != data.CategoryDigests {
+		t.Fatalf("parse(%q) = %d, %q, %t", token, userID, category, ok)
+	}
+
+	// links sent before there were categories
+	payload := "42." + legacyWeeklyDigest
+	userID, category, ok = app.parseUnsubscribeToken(payload + "." + app.signUnsubscribe(payload))
+	if !ok || userID != 42 || category != data.CategoryDigests {
+		t.Fatalf("parse legacy token = %d, %q, %t", userID, category, ok)
+	}
+
+	link := app.unsubscribeURL(42, data.CategoryDigests)
+	if !strings.HasPrefix(link, "https://api.example.com/v1/unsubscribe?token=") {
+		t.Errorf("unexpected link %q", link)
+	}
+}
+
+func TestUnsubscribeToken_RejectsTampering(t *testing.T) {
+	app := newTestAppUnsubscribe()
+	token := app.unsubscribeToken(42, data.CategoryDigests)
+	_, signature, _ := strings.Cut(token, data.CategoryDigests)
+
+	other := newTestAppUnsubscribe()
+	other.config.unsubscribe.secret = "another-secret"
+
+	tests := map[string]string{
+		"other user":   "43." + data.CategoryDigests + signature,
+		"other secret": other.unsubscribeToken(42, data.CategoryDigests),
+		"no signature": "42." + data.CategoryDigests,
+		"empty":        "",
+	}
+
+	for name, token := range tests {
+		if _, _, ok := app.parseUnsubscribeToken(token); ok {
+			t.Errorf("%s: expected %q to be rejected", name, token)
+		}
+	}
+}
+
+func TestUnsubscribeHandler_InvalidToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing", ""},
+		{"bad signature", "?token=" + url.QueryEscape("42.digests.bad")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestAppUnsubscribe()
+			req := httptest.NewRequest(http.MethodPost, "/v1/unsubscribe"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			app.unsubscribeHandler(rr, req)
+
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnsubscribeHandler_UnknownCategory(t *testing.T) {
+	app := newTestAppUnsubscribe()
+	token := app.unsubscribeToken(42, "no_such_category")
+	req := httptest.NewRequest(http.MethodPost, "/v1/unsubscribe?token="+url.QueryEscape(token), nil)
+	rr := httptest.NewRecorder()
+
+	app.unsubscribeHandler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
+
+func TestUnsubscribeHandler_SecurityIsMandatory(t *testing.T) {
+	app := newTestAppUnsubscribe()
+	token := app.unsubscribeToken(42, data.CategorySecurity)
+	req := httptest.NewRequest(http.MethodPost, "/v1/unsubscribe?token="+url.QueryEscape(token), nil)
+	rr := httptest.NewRecorder()
+
+	app.unsubscribeHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "mandatory_notification") {
+		t.Errorf("expected mandatory_notification in body=%s", rr.Body.String())
+	}
+}
+
+func TestConfirmUnsubscribeHandler_OnlyAsks(t *testing.T) {
+	app := newTestAppUnsubscribe()
+	token := app.unsubscribeToken(42, data.CategoryReminders)
+	req := httptest.NewRequest(http.MethodGet, "/v1/unsubscribe?token="+url.QueryEscape(token), nil)
+	rr := httptest.NewRecorder()
+
+	// there is no database, so changing the preference would panic
+	app.confirmUnsubscribeHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	for _, want := range []string{`<form method="post">`, "Stop getting reminder emails?"} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("expected %q in body=%s", want, rr.Body.String())
+		}
+	}
+}
+
+func TestConfirmUnsubscribeHandler_InvalidToken(t *testing.T) {
+	app := newTestAppUnsubscribe()
+	req := httptest.NewRequest(http.MethodGet, "/v1/unsubscribe?token="+url.QueryEscape("42.digests.bad"), nil)
+	rr := httptest.NewRecorder()
+
+	app.confirmUnsubscribeHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+}
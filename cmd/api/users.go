@@ -4,7 +4,6 @@ package main
 import (
 	"errors"
 	"net/http"
-	"time"
 
 	"github.com/aiycoleman/Study-Mate/internal/data"
 	"github.com/aiycoleman/Study-Mate/internal/validator"
@@ -41,7 +40,7 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	v := validator.New()
 	data.ValidateUser(v, user)
 	if !v.Valid() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -50,8 +49,8 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrDuplicateEmail):
-			v.AddError("email", "a user with this email address already exists")
-			app.failedValidationResponse(w, r, v.Errors)
+			v.AddError("email", "duplicate_email")
+			app.failedValidationResponse(w, r, v)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
@@ -65,30 +64,20 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Generate a new activation token which expires in 3 days
-	token, err := app.tokenModel.New(user.ID, 3*24*time.Hour, data.ScopeActivation)
+	// Queue the welcome email so it is sent, and retried, by the job workers.
+	// The job creates the activation token, so it isn't kept in the queue
+	_, err = app.jobModel.Enqueue(data.JobSendActivation, data.SendActivationPayload{
+		UserID: user.ID,
+		Locale: app.requestLanguage(r),
+	})
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
+		app.logger.Error(err.Error())
 	}
 
 	data := envelope{
 		"user": user,
 	}
 
-	// Send the email as a  go routine
-	app.background(func() {
-		data := map[string]any{
-			"activationToken": token.Plaintext,
-			"userID":          user.ID,
-		}
-
-		err = app.mailer.Send(user.Email, "user_welcome.tmpl", data)
-		if err != nil {
-			app.logger.Error(err.Error())
-		}
-	})
-
 	// Status code 201 resource created
 	err = app.writeJSON(w, http.StatusCreated, data, nil)
 	if err != nil {
@@ -111,7 +100,7 @@ func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Reque
 	v := validator.New()
 	data.ValidateTokenPlaintext(v, incomingData.TokenPlaintext)
 	if !v.IsEmpty() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -120,8 +109,8 @@ func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Reque
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
-			v.AddError("token", "invalid or expired activation token")
-			app.failedValidationResponse(w, r, v.Errors)
+			v.AddError("token", "invalid_token")
+			app.failedValidationResponse(w, r, v)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
@@ -187,7 +176,7 @@ func (app *application) listUsersHandler(w http.ResponseWriter, r *http.Request)
 	// Check if the filters are valid
 	data.ValidateFilters(v, queryParametersData.Filters)
 	if !v.IsEmpty() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -255,7 +244,7 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 	v := validator.New()
 	data.ValidateUser(v, user)
 	if !v.Valid() {
-		app.failedValidationResponse(w, r, v.Errors)
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -263,8 +252,8 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrDuplicateEmail):
-			v.AddError("email", "a user with this email already exists")
-			app.failedValidationResponse(w, r, v.Errors)
+			v.AddError("email", "duplicate_email")
+			app.failedValidationResponse(w, r, v)
 		case errors.Is(err, data.ErrEditConflict):
 			app.editConflictResponse(w, r)
 		default:
@@ -331,8 +320,10 @@ func (app *application) updatePasswordHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if input.NewPassword == "" {
-		app.failedValidationResponse(w, r, map[string]string{"new_password": "must be provided"})
+	v := validator.New()
+	v.Check(input.NewPassword != "", "new_password", "required")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
 		return
 	}
 
@@ -349,3 +340,63 @@ func (app *application) updatePasswordHandler(w http.ResponseWriter, r *http.Req
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// GET /v1/users/me/preferences
+func (app *application) showPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"preferences": user.Preferences}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// PATCH /v1/users/me/preferences
+func (app *application) updatePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	var incomingData struct {
+		TimeZone       *string `json:"time_zone"`
+		Locale         *string `json:"locale"`
+		FirstDayOfWeek *int    `json:"first_day_of_week"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if incomingData.TimeZone != nil {
+		user.TimeZone = *incomingData.TimeZone
+	}
+	if incomingData.Locale != nil {
+		user.Locale = *incomingData.Locale
+	}
+	if incomingData.FirstDayOfWeek != nil {
+		user.FirstDayOfWeek = *incomingData.FirstDayOfWeek
+	}
+
+	v := validator.New()
+	data.ValidatePreferences(v, user.Preferences)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v)
+		return
+	}
+
+	err = app.userModel.UpdatePreferences(user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"preferences": user.Preferences}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -2,24 +2,13 @@ package main
 
 import (
     "bytes"
+    "encoding/json"
     "net/http"
     "net/http/httptest"
     "testing"
-    "io"
-    "log/slog"
+    "github.com/aiycoleman/Study-Mate/internal/data"
 )
 
-var testApp *application
-
-func newTestApp() *application {
-    if testApp != nil {
-        return testApp
-    }
-    logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-    testApp = &application{logger: logger}
-    return testApp
-}
-
 func TestRegisterUserHandler_BadJSON(t *testing.T) {
     app := newTestApp()
     req := httptest.NewRequest(http.MethodPost, "/v1/users/register", bytes.NewBufferString("{bad json"))
@@ -107,4 +96,95 @@ func TestUpdatePasswordHandler_MissingNewPassword(t *testing.T) {
     if rr.Code != http.StatusNotFound {
         t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
     }
-}
\ No newline at end of file
+}
+
+func TestUpdatePreferencesHandler_InvalidData(t *testing.T) {
+    tests := []string{
+        `{"time_zone":"Not/AZone"}`,
+        `{"time_zone":"Local"}`,
+        `{"locale":"english"}`,
+        `{"first_day_of_week":7}`,
+    }
+
+    for _, payload := range tests {
+        app := newTestApp()
+        req := httptest.NewRequest(http.MethodPatch, "/v1/users/me/preferences", bytes.NewBufferString(payload))
+        req.Header.Set("Content-Type", "application/json")
+        usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com",
+            Preferences: data.Preferences{TimeZone: "UTC", Locale: "en", FirstDayOfWeek: 1}}
+        req = app.contextSetUser(req, usr)
+        rr := httptest.NewRecorder()
+
+        app.updatePreferencesHandler(rr, req)
+
+        if rr.Code != http.StatusUnprocessableEntity {
+            t.Fatalf("payload %s: expected status %d; got %d; body=%s", payload, http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+        }
+    }
+}
+
+func TestUpdatePreferencesHandler_BadJSON(t *testing.T) {
+    app := newTestApp()
+    req := httptest.NewRequest(http.MethodPatch, "/v1/users/me/preferences", bytes.NewBufferString(`{"time_zone":`))
+    usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com"}
+    req = app.contextSetUser(req, usr)
+    rr := httptest.NewRecorder()
+
+    app.updatePreferencesHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestFailedValidation_Localized(t *testing.T) {
+    tests := []struct {
+        name           string
+        acceptLanguage string
+        locale         string
+        want           string
+    }{
+        {"default", "", "en", "must be between 0 (Sunday) and 6 (Saturday)"},
+        {"accept language", "es-MX,es;q=0.9", "en", "debe estar entre 0 (domingo) y 6 (sábado)"},
+        {"stored locale", "", "es", "debe estar entre 0 (domingo) y 6 (sábado)"},
+        {"header wins", "en", "es", "must be between 0 (Sunday) and 6 (Saturday)"},
+        {"unsupported header", "fr", "es", "debe estar entre 0 (domingo) y 6 (sábado)"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            app := newTestApp()
+            req := httptest.NewRequest(http.MethodPatch, "/v1/users/me/preferences", bytes.NewBufferString(`{"first_day_of_week":7}`))
+            req.Header.Set("Content-Type", "application/json")
+            if tt.acceptLanguage != "" {
+                req.Header.Set("Accept-Language", tt.acceptLanguage)
+            }
+            usr := &data.User{ID: 1, Username: "testuser", Email: "t@example.com",
+                Preferences: data.Preferences{TimeZone: "UTC", Locale: tt.locale, FirstDayOfWeek: 1}}
+            req = app.contextSetUser(req, usr)
+            rr := httptest.NewRecorder()
+
+            app.updatePreferencesHandler(rr, req)
+
+            if rr.Code != http.StatusUnprocessableEntity {
+                t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+            }
+
+            var body struct {
+                Error map[string]struct {
+                    Code    string `json:"code"`
+                    Message string `json:"message"`
+                } `json:"error"`
+            }
+            if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+                t.Fatalf("decoding body: %v", err)
+            }
+            if body.Error["first_day_of_week"].Code != "invalid_day_of_week" {
+                t.Errorf("code = %q; want %q", body.Error["first_day_of_week"].Code, "invalid_day_of_week")
+            }
+            if body.Error["first_day_of_week"].Message != tt.want {
+                t.Errorf("message = %q; want %q", body.Error["first_day_of_week"].Message, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,109 @@
+// Filename: internal/data/daily_quotes.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Daily returns the user's quote for day, a date in their time zone. The
+// first request of the day picks it and later ones get the same quote,
+// unless they can no longer see it or have hidden it. The pick rotates through the approved
+// quotes the user can see and hasn't hidden in ID order, starting after
+// yesterday's, and skips any the user got in the last repeatDays days.
+// When every quote is that recent, the one shown longest ago is used. It
+// returns ErrRecordNotFound when there are no quotes
+func (q QuoteModel) Daily(userID int64, day time.Time, repeatDays int) (*Quote, error) {
+	quote, err := q.dailyPick(userID, day)
+	if !errors.Is(err, ErrRecordNotFound) {
+		return quote, err
+	}
+
+	query := `
+		WITH recent AS (
+			SELECT quote_id, MAX(day) AS last_day
+			FROM daily_quotes
+			WHERE user_id = $1 AND day < $2::date AND day >= $2::date - $3::int
+			GROUP BY quote_id
+		), previous AS (
+			SELECT quote_id
+			FROM daily_quotes
+			WHERE user_id = $1 AND day < $2::date
+			ORDER BY day DESC
+			LIMIT 1
+		)
+		INSERT INTO daily_quotes (user_id, day, quote_id)
+		SELECT $1, $2::date, q.quote_id
+		FROM quotes q
+		LEFT JOIN recent r ON r.quote_id = q.quote_id
+		WHERE q.status = 'approved'
+		AND ` + visibleTo("$1", "false") + `
+		AND ` + notHiddenFrom("$1") + `
+		ORDER BY r.last_day IS NOT NULL, r.last_day ASC,
+		         q.quote_id <= COALESCE((SELECT quote_id FROM previous), 0), q.quote_id ASC
+		LIMIT 1
+		ON CONFLICT (user_id, day) DO UPDATE
+		SET quote_id = EXCLUDED.quote_id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM quotes q
+			WHERE q.quote_id = daily_quotes.quote_id AND q.status = 'approved'
+			AND ` + visibleTo("$1", "false") + `
+			AND ` + notHiddenFrom("$1") + `
+		)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = q.DB.ExecContext(ctx, query, userID, day.Format(DateLayout), repeatDays)
+	if err != nil {
+		return nil, err
+	}
+
+	// another request may have picked first, so read back whichever won
+	return q.dailyPick(userID, day)
+}
+
+func (q QuoteModel) dailyPick(userID int64, day time.Time) (*Quote, error) {
+	query := `
+		SELECT q.quote_id, q.user_id, COALESCE(u.username, ''), q.content, q.likes_count,
+		       q.status, q.visibility, q.moderation_reason, q.author, q.source, ` + quoteTagsColumn + `, q.created_at
+		FROM daily_quotes d
+		JOIN quotes q ON q.quote_id = d.quote_id
+		LEFT JOIN users u ON q.user_id = u.id
+		WHERE d.user_id = $1 AND d.day = $2::date AND q.status = 'approved'
+		AND ` + visibleTo("$1", "false") + `
+		AND ` + notHiddenFrom("$1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var quote Quote
+	err := q.DB.QueryRowContext(ctx, query, userID, day.Format(DateLayout)).Scan(
+		&quote.ID,
+		&quote.UserID,
+		&quote.Username,
+		&quote.Content,
+		&quote.Likes,
+		&quote.Status,
+		&quote.Visibility,
+		&quote.ModerationReason,
+		&quote.Author,
+		&quote.Source,
+		pq.Array(&quote.Tags),
+		&quote.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &quote, nil
+}
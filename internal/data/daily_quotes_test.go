@@ -0,0 +1,71 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDaily_Rotation(t *testing.T) {
+	db := newTestDB(t)
+	quotes := QuoteModel{DB: db}
+
+	alice := insertTestUser(t, db, "alice")
+	bob := insertTestUser(t, db, "bob")
+	first := insertTestQuote(t, db, alice.ID, "First", QuoteApproved, VisibilityPublic)
+	second := insertTestQuote(t, db, alice.ID, "Second", QuoteApproved, VisibilityPublic)
+	third := insertTestQuote(t, db, alice.ID, "Third", QuoteApproved, VisibilityPublic)
+	insertTestQuote(t, db, alice.ID, "Not approved", QuotePending, VisibilityPublic)
+	insertTestQuote(t, db, alice.ID, "Private", QuoteApproved, VisibilityPrivate)
+
+	// the picks go through the quotes bob can see in ID order, and come
+	// back to the first once it is more than two days old
+	day := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	for i, want := range []*Quote{first, second, third, first} {
+		got, err := quotes.Daily(bob.ID, day.AddDate(0, 0, i), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != want.ID {
+			t.Errorf("day %d: got %q; want %q", i+1, got.Content, want.Content)
+		}
+
+		// later requests on the same day get the same quote
+		again, err := quotes.Daily(bob.ID, day.AddDate(0, 0, i), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.ID != got.ID {
+			t.Errorf("day %d: got %q on the second request; want %q again", i+1, again.Content, got.Content)
+		}
+	}
+}
+
+func TestDaily_HiddenQuoteIsReplaced(t *testing.T) {
+	db := newTestDB(t)
+	quotes := QuoteModel{DB: db}
+
+	alice := insertTestUser(t, db, "alice")
+	bob := insertTestUser(t, db, "bob")
+	first := insertTestQuote(t, db, alice.ID, "First", QuoteApproved, VisibilityPublic)
+	second := insertTestQuote(t, db, alice.ID, "Second", QuoteApproved, VisibilityPublic)
+
+	day := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	got, err := quotes.Daily(bob.ID, day, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != first.ID {
+		t.Fatalf("got %q; want %q", got.Content, first.Content)
+	}
+
+	if err := quotes.Hide(bob.ID, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err = quotes.Daily(bob.ID, day, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != second.ID {
+		t.Errorf("got %q after hiding today's quote; want %q", got.Content, second.Content)
+	}
+}
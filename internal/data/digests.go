@@ -0,0 +1,181 @@
+// Filename: internal/data/digests.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DigestRecipient is a user whose weekly digest is due. WeekStart is the
+// Monday of the week to summarize, in the user's time zone
+type DigestRecipient struct {
+	UserID         int64
+	Username       string
+	Email          string
+	Locale         string
+	TimeZone       string
+	FirstDayOfWeek int
+	WeekStart      string // in DateLayout
+	Today          string // in DateLayout
+}
+
+// DigestGoal is a goal listed in a digest
+type DigestGoal struct {
+	ID         int64
+	GoalText   string
+	TargetDate string // in DateLayout, empty for recurring goals
+}
+
+type DigestModel struct {
+	DB *sql.DB
+}
+
+// DueDigests finds the users for whom it is Monday, at or after hour
+// o'clock in their own time zone, and who haven't been sent the digest for
+// the week that just ended. Users who are not activated or turned digests
+// off on every channel are skipped
+func (m DigestModel) DueDigests(hour int, limit int) ([]*DigestRecipient, error) {
+	query := `
+		SELECT u.id, u.username, u.email, u.locale, u.time_zone, u.first_day_of_week,
+		       (t.now::date - 7)::text, t.now::date::text
+		FROM users u
+		CROSS JOIN LATERAL (SELECT NOW() AT TIME ZONE u.time_zone AS now) t
+		WHERE u.activated
+		AND ` + anyChannelEnabled(CategoryDigests) + `
+		AND EXTRACT(ISODOW FROM t.now) = 1
+		AND EXTRACT(HOUR FROM t.now) >= $1
+		AND NOT EXISTS (
+			SELECT 1 FROM weekly_digests d
+			WHERE d.user_id = u.id AND d.week_start = t.now::date - 7
+		)
+		ORDER BY u.id ASC
+		LIMIT $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, hour, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []*DigestRecipient
+	for rows.Next() {
+		var recipient DigestRecipient
+		err := rows.Scan(
+			&recipient.UserID,
+			&recipient.Username,
+			&recipient.Email,
+			&recipient.Locale,
+			&recipient.TimeZone,
+			&recipient.FirstDayOfWeek,
+			&recipient.WeekStart,
+			&recipient.Today,
+		)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, &recipient)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// Claim records the digest as sent before it goes out, so it is only sent
+// once. It returns false when it was already claimed
+func (m DigestModel) Claim(recipient *DigestRecipient) (bool, error) {
+	query := `
+		INSERT INTO weekly_digests (user_id, week_start)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, recipient.UserID, recipient.WeekStart)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// Release removes a claim so the digest is tried again on the next run
+func (m DigestModel) Release(recipient *DigestRecipient) error {
+	query := `
+		DELETE FROM weekly_digests
+		WHERE user_id = $1 AND week_start = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, recipient.UserID, recipient.WeekStart)
+	return err
+}
+
+// CompletedGoals lists the goals the user completed between the two dates
+// (inclusive) in their time zone
+func (m DigestModel) CompletedGoals(userID int64, from, to, tz string) ([]*DigestGoal, error) {
+	query := `
+		SELECT goal_id, goal_text, COALESCE(target_date::text, '')
+		FROM goals
+		WHERE user_id = $1
+		AND is_completed
+		AND (completed_at AT TIME ZONE $4)::date BETWEEN $2::date AND $3::date
+		ORDER BY completed_at ASC, goal_id ASC`
+
+	return m.goals(query, userID, from, to, tz)
+}
+
+// DueGoals lists the user's open one-time goals due between the two dates
+// (inclusive)
+func (m DigestModel) DueGoals(userID int64, from, to string) ([]*DigestGoal, error) {
+	query := `
+		SELECT goal_id, goal_text, target_date::text
+		FROM goals
+		WHERE user_id = $1
+		AND is_completed IS NOT TRUE
+		AND goal_type = 'one_time'
+		AND target_date BETWEEN $2::date AND $3::date
+		ORDER BY target_date ASC, goal_id ASC`
+
+	return m.goals(query, userID, from, to)
+}
+
+func (m DigestModel) goals(query string, args ...any) ([]*DigestGoal, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []*DigestGoal{}
+	for rows.Next() {
+		var goal DigestGoal
+		err := rows.Scan(&goal.ID, &goal.GoalText, &goal.TargetDate)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, &goal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return goals, nil
+}
@@ -0,0 +1,137 @@
+// Filename: internal/data/events.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// EventsChannel is what the record_event trigger NOTIFYs, with a payload of
+// "<user id>:<event id>"
+const EventsChannel = "events"
+
+// Event is a change to one of a user's sessions, goals or notifications,
+// such as goal.updated. Data holds the ID of what changed
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type EventModel struct {
+	DB *sql.DB
+}
+
+// LatestID is the ID of the user's newest event, or 0 when there are none.
+// A new stream starts after it
+func (m EventModel) LatestID(userID int64) (int64, error) {
+	query := `
+		SELECT COALESCE(MAX(id), 0)
+		FROM events
+		WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var id int64
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&id)
+	return id, err
+}
+
+// RecentIDs lists the IDs of the user's events up to upToID that were
+// recorded within window, which a new stream treats as already sent
+func (m EventModel) RecentIDs(userID int64, upToID int64, window time.Duration) ([]int64, error) {
+	query := `
+		SELECT id
+		FROM events
+		WHERE user_id = $1 AND id <= $2
+		AND created_at >= NOW() - make_interval(secs => $3)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, upToID, window.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Since lists up to limit of the user's events after afterID, oldest first.
+// Event IDs are handed out when a transaction inserts the event, not when it
+// commits, so an event can show up after ones with higher IDs. Since also
+// returns the events up to afterID recorded within window, except the ones
+// in skip, so that those late events aren't missed
+func (m EventModel) Since(userID int64, afterID int64, window time.Duration, skip []int64, limit int) ([]*Event, error) {
+	query := `
+		SELECT id, type, data, created_at
+		FROM events
+		WHERE user_id = $1
+		AND (id > $2 OR created_at >= NOW() - make_interval(secs => $3))
+		AND NOT id = ANY($4::bigint[])
+		ORDER BY id ASC
+		LIMIT $5`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, afterID, window.Seconds(), pq.Array(skip), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+	for rows.Next() {
+		var event Event
+		err := rows.Scan(&event.ID, &event.Type, &event.Data, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Prune deletes the events older than retention. It returns how many were
+// deleted
+func (m EventModel) Prune(retention time.Duration) (int64, error) {
+	query := `
+		DELETE FROM events
+		WHERE created_at < NOW() - make_interval(secs => $1)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
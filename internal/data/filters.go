@@ -15,12 +15,12 @@ type Filters struct {
 }
 
 func ValidateFilters(v *validator.Validator, f Filters) {
-	v.Check(f.Page > 0, "page", "must be greater than zero")
-	v.Check(f.Page <= 500, "page", "must be a maximum of 500")
-	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
-	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
+	v.Check(f.Page > 0, "page", "greater_than_zero")
+	v.Check(f.Page <= 500, "page", "max_value", 500)
+	v.Check(f.PageSize > 0, "page_size", "greater_than_zero")
+	v.Check(f.PageSize <= 100, "page_size", "max_value", 100)
 
-	v.Check(validator.PermittedValue(f.Sort, f.SortSafeList...), "sort", "invalid sort value")
+	v.Check(validator.PermittedValue(f.Sort, f.SortSafeList...), "sort", "invalid_sort")
 }
 
 // Calculate how many records to send back
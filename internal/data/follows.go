@@ -0,0 +1,148 @@
+// Filename: internal/data/follows.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Follow is a user on the other end of a follow. Until the followee approves
+// it, a follow is only a request
+type Follow struct {
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username"`
+	Approved   bool      `json:"approved"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowModel struct {
+	DB *sql.DB
+}
+
+// Follow asks for the follower to follow the followee. Once the followee
+// approves it, the follower sees their followers-only quotes. It reports
+// whether the request is new, since asking again does nothing, and returns
+// ErrRecordNotFound when the followee doesn't exist
+func (m FollowModel) Follow(followerID int64, followeeID int64) (bool, error) {
+	query := `
+		WITH followee AS (
+			SELECT id
+			FROM users
+			WHERE id = $2
+		), inserted AS (
+			INSERT INTO follows (follower_id, followee_id)
+			SELECT $1, id
+			FROM followee
+			ON CONFLICT DO NOTHING
+			RETURNING 1
+		)
+		SELECT EXISTS (SELECT 1 FROM followee), EXISTS (SELECT 1 FROM inserted)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var exists, created bool
+	err := m.DB.QueryRowContext(ctx, query, followerID, followeeID).Scan(&exists, &created)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, ErrRecordNotFound
+	}
+
+	return created, nil
+}
+
+// Approve lets the follower see the followee's followers-only quotes. It
+// returns ErrRecordNotFound when the follower hasn't asked to follow them
+func (m FollowModel) Approve(followerID int64, followeeID int64) error {
+	query := `
+		UPDATE follows
+		SET approved = true
+		WHERE follower_id = $1 AND followee_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Unfollow stops the follower following the followee, or drops their
+// request to. Followees use it to remove a follower too
+func (m FollowModel) Unfollow(followerID int64, followeeID int64) error {
+	query := `
+		DELETE FROM follows
+		WHERE follower_id = $1 AND followee_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, followerID, followeeID)
+	return err
+}
+
+// Followers lists the users who follow the user or asked to
+func (m FollowModel) Followers(userID int64, filters Filters) ([]*Follow, Metadata, error) {
+	return m.list(`
+		SELECT COUNT(*) OVER(), u.id, u.username, f.approved, f.created_at AS followed_at
+		FROM follows f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.followee_id = $1
+		ORDER BY `+filters.sortColumn()+` `+filters.sortDirection()+`, u.id ASC
+		LIMIT $2 OFFSET $3`, userID, filters)
+}
+
+// Following lists the users the user follows or asked to
+func (m FollowModel) Following(userID int64, filters Filters) ([]*Follow, Metadata, error) {
+	return m.list(`
+		SELECT COUNT(*) OVER(), u.id, u.username, f.approved, f.created_at AS followed_at
+		FROM follows f
+		JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = $1
+		ORDER BY `+filters.sortColumn()+` `+filters.sortDirection()+`, u.id ASC
+		LIMIT $2 OFFSET $3`, userID, filters)
+}
+
+func (m FollowModel) list(query string, userID int64, filters Filters) ([]*Follow, Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	follows := []*Follow{}
+	for rows.Next() {
+		var follow Follow
+		err := rows.Scan(&totalRecords, &follow.UserID, &follow.Username, &follow.Approved, &follow.FollowedAt)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		follows = append(follows, &follow)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return follows, metadata, nil
+}
@@ -0,0 +1,63 @@
+// Filename: internal/data/fuzzy.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+)
+
+// How similar, from 0 to 1, a search has to be to some words of a column
+// for a fuzzy match. Usernames are short, so a typo changes more of them
+// and they need a closer match to avoid finding everyone. fuzzyQuery sets
+// pg_trgm.word_similarity_threshold to the lowest of these, so the trigram
+// indexes find every candidate and the rest are checked by fuzzyMatch
+const (
+	fuzzyTextThreshold = 0.4
+	fuzzyNameThreshold = 0.5
+)
+
+// fuzzyQuery runs a query that uses fuzzyMatch. The <% operator goes by
+// pg_trgm.word_similarity_threshold, which defaults to a stricter 0.6, so
+// the query runs in a read-only transaction that sets it first. done closes
+// the rows and ends the transaction
+func fuzzyQuery(ctx context.Context, db *sql.DB, query string, args ...any) (rows *sql.Rows, done func(), err error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	threshold := strconv.FormatFloat(min(fuzzyTextThreshold, fuzzyNameThreshold), 'f', -1, 64)
+	_, err = tx.ExecContext(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`, threshold)
+	if err == nil {
+		rows, err = tx.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	return rows, func() {
+		rows.Close()
+		tx.Rollback()
+	}, nil
+}
+
+// fuzzyMatch is a filter that keeps the rows where the column matches the
+// search in the given parameter. It matches the words in any order as
+// before, any part of a word regardless of case, or words spelled slightly
+// differently going by their trigram similarity. Each of these can use an
+// index on the column. An empty search keeps every row. Queries using it
+// run through fuzzyQuery
+func fuzzyMatch(column string, searchParam string, threshold float64) string {
+	return `(` + searchParam + ` = ''
+			OR to_tsvector('simple', ` + column + `) @@ plainto_tsquery('simple', ` + searchParam + `)
+			OR ` + column + ` ILIKE '%' || ` + likeEscaped(searchParam) + ` || '%'
+			OR (` + searchParam + ` <% ` + column + ` AND word_similarity(` + searchParam + `, ` + column + `) >= ` + strconv.FormatFloat(threshold, 'f', -1, 64) + `))`
+}
+
+// likeEscaped escapes the LIKE wildcards in the search in the given
+// parameter, so they match themselves
+func likeEscaped(searchParam string) string {
+	return `replace(replace(replace(` + searchParam + `, '\', '\\'), '%', '\%'), '_', '\_')`
+}
@@ -4,26 +4,336 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"math"
 	"time"
 
 	"github.com/aiycoleman/Study-Mate/internal/validator"
 )
 
 type Goal struct {
-	ID          int64     `json:"id"`
-	UserID      int64     `json:"user_id"`
-	GoalText    string    `json:"goal_text"`
-	TargetDate  time.Time `json:"target_date"`
-	IsCompleted bool      `json:"is_completed"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID            int64         `json:"id"`
+	UserID        int64         `json:"user_id"`
+	GoalText      string        `json:"goal_text"`
+	TargetDate    *time.Time    `json:"target_date,omitempty"` // optional for recurring goals
+	IsCompleted   bool          `json:"is_completed"`
+	CompletedAt   *time.Time    `json:"completed_at,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	TargetMinutes *int          `json:"target_minutes,omitempty"` // e.g. 1200 for "20 hours"
+	Subject       string        `json:"subject,omitempty"`        // only count sessions for this subject
+	Tag           string        `json:"tag,omitempty"`            // only count sessions with this tag
+	Progress      *GoalProgress `json:"progress,omitempty"`
+
+	GoalType      string         `json:"goal_type"`              // "one_time" or "recurring"
+	Period        string         `json:"period,omitempty"`       // how often a recurring goal repeats
+	TargetCount   *int           `json:"target_count,omitempty"` // active days needed each period
+	HabitProgress *HabitProgress `json:"habit_progress,omitempty"`
+
+	MilestoneProgress *MilestoneProgress `json:"milestone_progress,omitempty"`
+	Milestones        []*Milestone       `json:"milestones,omitempty"` // only when embedded
+}
+
+// GoalProgress is computed from the linked study sessions of a measurable
+// goal. It is never stored
+type GoalProgress struct {
+	CurrentMinutes       int     `json:"current_minutes"`
+	TargetMinutes        int     `json:"target_minutes"`
+	Percent              float64 `json:"percent"`
+	RemainingMinutes     int     `json:"remaining_minutes"`
+	DaysRemaining        int     `json:"days_remaining"`
+	DailyPace            float64 `json:"daily_pace_minutes"`     // average per day so far
+	RequiredDailyMinutes float64 `json:"required_daily_minutes"` // needed per day to finish on time
+	ProjectedMinutes     int     `json:"projected_minutes"`      // by the target date at the current pace
+	ForecastDate         string  `json:"forecast_completion_date,omitempty"`
+	OnTrack              bool    `json:"on_track"`
+}
+
+// HabitProgress is the progress of a recurring goal in its current period.
+// A day counts once whether it has a check-in, linked sessions or both
+type HabitProgress struct {
+	PeriodStart string `json:"period_start"`
+	PeriodEnd   string `json:"period_end"`
+	Count       int    `json:"count"`
+	TargetCount int    `json:"target_count"`
+	Remaining   int    `json:"remaining"`
+	Met         bool   `json:"met"`
+}
+
+// Goal types and the periods of recurring goals
+const (
+	GoalTypeOneTime   = "one_time"
+	GoalTypeRecurring = "recurring"
+
+	PeriodDaily   = "daily"
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+)
+
+// MilestoneProgress is derived from the completed milestones of a goal
+type MilestoneProgress struct {
+	Total     int     `json:"total"`
+	Completed int     `json:"completed"`
+	Percent   float64 `json:"percent"`
 }
 
 // Validation checks for Goal input
 func ValidateGoal(v *validator.Validator, goal *Goal) {
-	v.Check(goal.GoalText != "", "goal_text", "must be provided")
-	v.Check(len(goal.GoalText) <= 255, "goal_text", "must not be more than 255 bytes long")
-	v.Check(!goal.TargetDate.IsZero(), "target_date", "must be provided")
-	v.Check(goal.UserID > 0, "user_id", "must be a valid user ID")
+	v.Check(goal.GoalText != "", "goal_text", "required")
+	v.Check(len(goal.GoalText) <= 255, "goal_text", "max_bytes", 255)
+	v.Check(goal.UserID > 0, "user_id", "invalid_user_id")
+	v.Check(validator.PermittedValue(goal.GoalType, GoalTypeOneTime, GoalTypeRecurring), "goal_type", "one_of", "one_time, recurring")
+
+	if goal.TargetMinutes != nil {
+		v.Check(*goal.TargetMinutes > 0, "target_minutes", "greater_than_zero")
+		v.Check(*goal.TargetMinutes <= 100_000, "target_minutes", "max_value", 100_000)
+	}
+	v.Check(len(goal.Subject) <= 100, "subject", "max_bytes", 100)
+	v.Check(len(goal.Tag) <= 50, "tag", "max_bytes", 50)
+
+	if goal.GoalType == GoalTypeRecurring {
+		// linked sessions count towards the period instead of a minute target
+		v.Check(goal.TargetMinutes == nil, "target_minutes", "not_for_recurring")
+		v.Check(validator.PermittedValue(goal.Period, PeriodDaily, PeriodWeekly, PeriodMonthly), "period", "one_of", "daily, weekly, monthly")
+		v.Check(goal.TargetCount != nil, "target_count", "required")
+		if goal.TargetCount != nil {
+			v.Check(*goal.TargetCount >= 1, "target_count", "min_value", 1)
+			v.Check(*goal.TargetCount <= periodDays(goal.Period), "target_count", "more_than_period_days")
+		}
+		return
+	}
+
+	v.Check(goal.TargetDate != nil, "target_date", "required")
+	v.Check(goal.Period == "", "period", "recurring_only")
+	v.Check(goal.TargetCount == nil, "target_count", "recurring_only")
+	v.Check(goal.TargetMinutes != nil || (goal.Subject == "" && goal.Tag == ""), "target_minutes", "required_with_subject")
+}
+
+// periodDays is the most days a period can have
+func periodDays(period string) int {
+	switch period {
+	case PeriodWeekly:
+		return 7
+	case PeriodMonthly:
+		return 31
+	default:
+		return 1
+	}
+}
+
+// PeriodStart returns the first day of the period that day falls in. Weeks
+// start on firstDayOfWeek (0 is Sunday)
+func PeriodStart(day time.Time, period string, firstDayOfWeek int) time.Time {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case PeriodWeekly:
+		return day.AddDate(0, 0, -((int(day.Weekday()) - firstDayOfWeek + 7) % 7))
+	case PeriodMonthly:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+// NextPeriodStart returns the first day of the period after the one that
+// starts on start
+func NextPeriodStart(start time.Time, period string) time.Time {
+	switch period {
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonthly:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// setProgress fills in the progress of a measurable goal from the minutes
+// studied so far. Days are counted in the owner's time zone
+func (goal *Goal) setProgress(minutes *int, tz string, now time.Time) {
+	if goal.TargetMinutes == nil || minutes == nil || goal.TargetDate == nil {
+		goal.Progress = nil
+		return
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	day := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	daysBetween := func(a, b time.Time) int {
+		return int(b.Sub(a).Hours() / 24)
+	}
+
+	start := day(goal.CreatedAt)
+	today := day(now)
+	target := time.Date(goal.TargetDate.Year(), goal.TargetDate.Month(), goal.TargetDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	p := &GoalProgress{
+		CurrentMinutes: *minutes,
+		TargetMinutes:  *goal.TargetMinutes,
+	}
+	p.Percent = math.Min(100, math.Round(float64(p.CurrentMinutes)/float64(p.TargetMinutes)*1000)/10)
+	p.RemainingMinutes = max(0, p.TargetMinutes-p.CurrentMinutes)
+	p.DaysRemaining = max(0, daysBetween(today, target)+1)
+
+	totalDays := max(1, daysBetween(start, target)+1)
+	elapsedDays := min(totalDays, max(1, daysBetween(start, today)+1))
+	p.DailyPace = math.Round(float64(p.CurrentMinutes)/float64(elapsedDays)*10) / 10
+	p.ProjectedMinutes = int(math.Round(float64(p.CurrentMinutes) / float64(elapsedDays) * float64(totalDays)))
+
+	if p.RemainingMinutes > 0 && p.DaysRemaining > 0 {
+		p.RequiredDailyMinutes = math.Round(float64(p.RemainingMinutes)/float64(p.DaysRemaining)*10) / 10
+	}
+	if p.RemainingMinutes > 0 && p.CurrentMinutes > 0 {
+		daysNeeded := math.Ceil(float64(p.RemainingMinutes) / (float64(p.CurrentMinutes) / float64(elapsedDays)))
+		p.ForecastDate = today.AddDate(0, 0, int(daysNeeded)).Format(DateLayout)
+	}
+	p.OnTrack = p.RemainingMinutes == 0 || p.ProjectedMinutes >= p.TargetMinutes
+
+	goal.Progress = p
+}
+
+// setHabitProgress fills in the current period of a recurring goal from the
+// period start and active day count worked out by the query
+func (goal *Goal) setHabitProgress(periodStart *time.Time, count int) {
+	if goal.GoalType != GoalTypeRecurring || periodStart == nil || goal.TargetCount == nil {
+		goal.HabitProgress = nil
+		return
+	}
+
+	start := time.Date(periodStart.Year(), periodStart.Month(), periodStart.Day(), 0, 0, 0, 0, time.UTC)
+	goal.HabitProgress = &HabitProgress{
+		PeriodStart: start.Format(DateLayout),
+		PeriodEnd:   NextPeriodStart(start, goal.Period).AddDate(0, 0, -1).Format(DateLayout),
+		Count:       count,
+		TargetCount: *goal.TargetCount,
+		Remaining:   max(0, *goal.TargetCount-count),
+		Met:         count >= *goal.TargetCount,
+	}
+}
+
+// setMilestoneProgress fills in the milestone progress, if the goal has any
+func (goal *Goal) setMilestoneProgress(total int, completed int) {
+	if total == 0 {
+		goal.MilestoneProgress = nil
+		return
+	}
+
+	goal.MilestoneProgress = &MilestoneProgress{
+		Total:     total,
+		Completed: completed,
+		Percent:   math.Round(float64(completed)/float64(total)*1000) / 10,
+	}
+}
+
+// The completed minutes that count towards a measurable goal g owned by u.
+// Sessions count from the start of the day the goal was created until the
+// end of the target date, both in the owner's time zone
+const goalProgressMinutes = `
+	CASE WHEN g.target_minutes IS NULL THEN NULL ELSE (
+		SELECT COALESCE(ROUND(SUM(EXTRACT(EPOCH FROM (s.end_time - s.start_time)) / 60)), 0)::int
+		FROM study_sessions s
+		WHERE s.user_id = g.user_id
+		AND s.is_completed
+		AND (g.subject = '' OR lower(s.subject) = lower(g.subject))
+		AND (g.tag = '' OR g.tag = ANY(s.tags))
+		AND s.start_time >= (g.created_at AT TIME ZONE COALESCE(u.time_zone, 'UTC'))::date::timestamp AT TIME ZONE COALESCE(u.time_zone, 'UTC')
+		AND s.start_time < (g.target_date + 1)::timestamp AT TIME ZONE COALESCE(u.time_zone, 'UTC')
+	) END`
+
+// The days on which the owner of a recurring goal g worked on it: days with
+// a check-in plus, when the goal is linked to a subject or tag, days with a
+// matching completed session. Days are in the owner's time zone
+const habitActiveDays = `
+	SELECT c.checkin_date AS day
+	FROM goal_checkins c
+	WHERE c.goal_id = g.goal_id
+	UNION
+	SELECT (s.start_time AT TIME ZONE COALESCE(u.time_zone, 'UTC'))::date
+	FROM study_sessions s
+	WHERE s.user_id = g.user_id
+	AND s.is_completed
+	AND (g.subject <> '' OR g.tag <> '')
+	AND (g.subject = '' OR lower(s.subject) = lower(g.subject))
+	AND (g.tag = '' OR g.tag = ANY(s.tags))`
+
+// The first day of the current period of a recurring goal g, using the
+// owner's time zone and first day of the week. Matches PeriodStart
+const habitPeriodStart = `
+	CASE g.period
+		WHEN 'daily' THEN (NOW() AT TIME ZONE COALESCE(u.time_zone, 'UTC'))::date
+		WHEN 'weekly' THEN (NOW() AT TIME ZONE COALESCE(u.time_zone, 'UTC'))::date
+			- ((EXTRACT(DOW FROM NOW() AT TIME ZONE COALESCE(u.time_zone, 'UTC'))::int - COALESCE(u.first_day_of_week, 1) + 7) % 7)
+		WHEN 'monthly' THEN date_trunc('month', NOW() AT TIME ZONE COALESCE(u.time_zone, 'UTC'))::date
+	END`
+
+// The columns every goal query selects, in the order scanGoal reads them.
+// The queries join the owner as u for their time zone
+const goalColumns = `
+		g.goal_id, g.user_id, g.goal_text, g.target_date, g.is_completed, g.created_at,
+		g.target_minutes, g.subject, g.tag, COALESCE(u.time_zone, 'UTC'),
+		` + goalProgressMinutes + `,
+		(SELECT COUNT(*) FROM goal_milestones ms WHERE ms.goal_id = g.goal_id),
+		(SELECT COUNT(*) FROM goal_milestones ms WHERE ms.goal_id = g.goal_id AND ms.is_completed),
+		g.goal_type, g.period, g.target_count,
+		CASE WHEN g.goal_type = 'recurring' THEN ` + habitPeriodStart + ` END,
+		CASE WHEN g.goal_type = 'recurring' THEN (
+			SELECT COUNT(*) FROM (` + habitActiveDays + `) d
+			WHERE d.day >= ` + habitPeriodStart + `
+		) ELSE 0 END,
+		g.completed_at`
+
+// Anything with a Scan method, so scanGoal works with *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanGoal reads the goalColumns into goal and computes its progress. Any
+// leading destinations (such as a window count) are scanned first
+func scanGoal(row rowScanner, goal *Goal, now time.Time, leading ...any) error {
+	var tz string
+	var progressMinutes *int
+	var milestonesTotal, milestonesCompleted int
+	var period sql.NullString
+	var periodStart *time.Time
+	var habitCount int
+
+	dest := append(leading,
+		&goal.ID,
+		&goal.UserID,
+		&goal.GoalText,
+		&goal.TargetDate,
+		&goal.IsCompleted,
+		&goal.CreatedAt,
+		&goal.TargetMinutes,
+		&goal.Subject,
+		&goal.Tag,
+		&tz,
+		&progressMinutes,
+		&milestonesTotal,
+		&milestonesCompleted,
+		&goal.GoalType,
+		&period,
+		&goal.TargetCount,
+		&periodStart,
+		&habitCount,
+		&goal.CompletedAt,
+	)
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return err
+	}
+
+	goal.setProgress(progressMinutes, tz, now)
+	goal.setMilestoneProgress(milestonesTotal, milestonesCompleted)
+	goal.Period = period.String
+	goal.setHabitProgress(periodStart, habitCount)
+
+	return nil
 }
 
 type GoalModel struct {
@@ -33,16 +343,16 @@ type GoalModel struct {
 // Insert a new goal into the database
 func (m GoalModel) Insert(goal *Goal) error {
 	query := `
-		INSERT INTO goals (user_id, goal_text, target_date, is_completed)
-		VALUES ($1, $2, $3, $4)
-		RETURNING goal_id, created_at`
+		INSERT INTO goals (user_id, goal_text, target_date, is_completed, target_minutes, subject, tag, goal_type, period, target_count, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, CASE WHEN $4 THEN NOW() END)
+		RETURNING goal_id, created_at, completed_at`
 
-	args := []any{goal.UserID, goal.GoalText, goal.TargetDate, goal.IsCompleted}
+	args := []any{goal.UserID, goal.GoalText, goalDate(goal.TargetDate), goal.IsCompleted, goal.TargetMinutes, goal.Subject, goal.Tag, goal.GoalType, goal.Period, goal.TargetCount}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&goal.ID, &goal.CreatedAt)
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&goal.ID, &goal.CreatedAt, &goal.CompletedAt)
 }
 
 // Get a specific goal from the database
@@ -52,23 +362,17 @@ func (m GoalModel) Get(id int64) (*Goal, error) {
 	}
 
 	query := `
-		SELECT goal_id, user_id, goal_text, target_date, is_completed, created_at
-		FROM goals
-		WHERE goal_id = $1`
+		SELECT ` + goalColumns + `
+		FROM goals g
+		LEFT JOIN users u ON u.id = g.user_id
+		WHERE g.goal_id = $1`
 
 	var goal Goal
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&goal.ID,
-		&goal.UserID,
-		&goal.GoalText,
-		&goal.TargetDate,
-		&goal.IsCompleted,
-		&goal.CreatedAt,
-	)
+	err := scanGoal(m.DB.QueryRowContext(ctx, query, id), &goal, time.Now())
 
 	if err != nil {
 		switch {
@@ -82,15 +386,18 @@ func (m GoalModel) Get(id int64) (*Goal, error) {
 	return &goal, nil
 }
 
-// Update a specific goal
+// Update a specific goal. Un-completing a completed goal marks it as
+// reopened, so CompleteReached leaves it alone
 func (m GoalModel) Update(goal *Goal) error {
 	query := `
 		UPDATE goals
-		SET goal_text = $1, target_date = $2, is_completed = $3
-		WHERE goal_id = $4
-		RETURNING goal_id, user_id, goal_text, target_date, is_completed, created_at`
+		SET goal_text = $1, target_date = $2, is_completed = $3, target_minutes = $4, subject = $5, tag = $6, target_count = $7,
+		    completed_at = CASE WHEN NOT $3 THEN NULL ELSE COALESCE(completed_at, NOW()) END,
+		    reopened = reopened OR (is_completed IS TRUE AND NOT $3)
+		WHERE goal_id = $8
+		RETURNING goal_id, user_id, goal_text, target_date, is_completed, created_at, target_minutes, subject, tag, target_count, completed_at`
 
-	args := []any{goal.GoalText, goal.TargetDate, goal.IsCompleted, goal.ID}
+	args := []any{goal.GoalText, goalDate(goal.TargetDate), goal.IsCompleted, goal.TargetMinutes, goal.Subject, goal.Tag, goal.TargetCount, goal.ID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -102,9 +409,23 @@ func (m GoalModel) Update(goal *Goal) error {
 		&goal.TargetDate,
 		&goal.IsCompleted,
 		&goal.CreatedAt,
+		&goal.TargetMinutes,
+		&goal.Subject,
+		&goal.Tag,
+		&goal.TargetCount,
+		&goal.CompletedAt,
 	)
 }
 
+// goalDate sends an optional date as text so the session time zone can't
+// shift the day
+func goalDate(date *time.Time) any {
+	if date == nil {
+		return nil
+	}
+	return date.Format(DateLayout)
+}
+
 // Delete a specific goal
 func (m GoalModel) Delete(id int64) error {
 	if id < 1 {
@@ -134,96 +455,107 @@ func (m GoalModel) Delete(id int64) error {
 
 	return nil
 }
+
+// CompleteReached marks the user's open one-time goals as completed once
+// their linked sessions reach the target, or once every one of their
+// milestones is done. Goals the user reopened are skipped. It returns how
+// many were completed
+func (m GoalModel) CompleteReached(userID int64) (int64, error) {
+	query := `
+		UPDATE goals
+		SET is_completed = true, completed_at = NOW()
+		WHERE goal_id IN (
+			SELECT g.goal_id
+			FROM goals g
+			LEFT JOIN users u ON u.id = g.user_id
+			WHERE g.user_id = $1
+			AND g.is_completed IS NOT TRUE
+			AND NOT g.reopened
+			AND g.goal_type = 'one_time'
+			AND (
+				(g.target_minutes IS NOT NULL AND ` + goalProgressMinutes + ` >= g.target_minutes)
+				OR (
+					g.target_minutes IS NULL
+					AND EXISTS (SELECT 1 FROM goal_milestones ms WHERE ms.goal_id = g.goal_id)
+					AND NOT EXISTS (SELECT 1 FROM goal_milestones ms WHERE ms.goal_id = g.goal_id AND NOT ms.is_completed)
+				)
+			)
+		)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
 // Get all goals for a specific user (filtered by user_id)
 func (m GoalModel) GetAllForUser(userID int64, goalText string, target_date time.Time, isCompleted bool, filters Filters) ([]*Goal, Metadata, error) {
-    query := `
-       SELECT COUNT(*) OVER(), goal_id, user_id, goal_text, target_date, is_completed, created_at
-       FROM goals
-       WHERE user_id = $1
-       AND (to_tsvector('simple', goal_text) @@ plainto_tsquery('simple', $2) OR $2 = '')
-       ORDER BY  + filters.sortColumn() +   + filters.sortDirection() + , goal_id ASC
-       LIMIT $3 OFFSET $4`
-
-    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-    defer cancel()
-
-    rows, err := m.DB.QueryContext(ctx, query, userID, goalText, filters.limit(), filters.offset())
-    if err != nil {
-       return nil, Metadata{}, err
-    }
-    defer rows.Close()
-
-    totalRecords := 0
-    var goals []*Goal
-
-    for rows.Next() {
-       var goal Goal
-       err := rows.Scan(
-          &totalRecords,
-          &goal.ID,
-          &goal.UserID,
-          &goal.GoalText,
-          &goal.TargetDate,
-          &goal.IsCompleted,
-          &goal.CreatedAt,
-       )
-       if err != nil {
-          return nil, Metadata{}, err
-       }
-       goals = append(goals, &goal)
-    }
-
-    if err = rows.Err(); err != nil {
-       return nil, Metadata{}, err
-    }
-
-    metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
-    return goals, metadata, nil
-}
+	query := `
+		SELECT COUNT(*) OVER(), ` + goalColumns + `
+		FROM goals g
+		LEFT JOIN users u ON u.id = g.user_id
+		WHERE g.user_id = $1
+		AND ` + fuzzyMatch("g.goal_text", "$2", fuzzyTextThreshold) + `
+		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, goal_id ASC
+		LIMIT $3 OFFSET $4`
 
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
 
+	rows, done, err := fuzzyQuery(ctx, m.DB, query, userID, goalText, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer done()
 
+	return scanGoalList(rows, filters)
+}
 
 // Get all goals (optionally filtered by completion status or goal text)
 func (m GoalModel) GetAll(goalText string, target_date time.Time, isCompleted bool, filters Filters) ([]*Goal, Metadata, error) {
 	query := `
-		SELECT COUNT(*) OVER(), goal_id, user_id, goal_text, target_date, is_completed, created_at
-		FROM goals
-		WHERE (to_tsvector('simple', goal_text) @@ plainto_tsquery('simple', $1) OR $1 = '')
-		AND ($2::boolean IS NULL OR is_completed = $2)
+		SELECT COUNT(*) OVER(), ` + goalColumns + `
+		FROM goals g
+		LEFT JOIN users u ON u.id = g.user_id
+		WHERE ` + fuzzyMatch("g.goal_text", "$1", fuzzyTextThreshold) + `
+		AND ($2::boolean IS NULL OR g.is_completed = $2)
 		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, goal_id ASC
 		LIMIT $3 OFFSET $4`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, goalText, isCompleted, filters.limit(), filters.offset())
+	rows, done, err := fuzzyQuery(ctx, m.DB, query, goalText, isCompleted, filters.limit(), filters.offset())
 	if err != nil {
 		return nil, Metadata{}, err
 	}
-	defer rows.Close()
+	defer done()
+
+	return scanGoalList(rows, filters)
+}
 
+// scanGoalList reads the rows of the goal listing queries, which all select
+// the same columns after the window count
+func scanGoalList(rows *sql.Rows, filters Filters) ([]*Goal, Metadata, error) {
 	totalRecords := 0
 	var goals []*Goal
+	now := time.Now()
 
 	for rows.Next() {
 		var goal Goal
-		err := rows.Scan(
-			&totalRecords,
-			&goal.ID,
-			&goal.UserID,
-			&goal.GoalText,
-			&goal.TargetDate,
-			&goal.IsCompleted,
-			&goal.CreatedAt,
-		)
+		err := scanGoal(rows, &goal, now, &totalRecords)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
 		goals = append(goals, &goal)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, Metadata{}, err
 	}
 
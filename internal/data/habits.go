@@ -0,0 +1,250 @@
+// Filename: internal/data/habits.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateCheckin = errors.New("duplicate check-in")
+
+// Checkin marks a day on which the owner worked on a recurring goal
+type Checkin struct {
+	ID          int64     `json:"id"`
+	GoalID      int64     `json:"goal_id"`
+	CheckinDate time.Time `json:"checkin_date"`
+	Note        string    `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PeriodResult is the recorded outcome of a closed period of a recurring goal
+type PeriodResult struct {
+	PeriodStart    string `json:"period_start"`
+	PeriodEnd      string `json:"period_end"`
+	CompletedCount int    `json:"completed_count"`
+	TargetCount    int    `json:"target_count"`
+	Succeeded      bool   `json:"succeeded"`
+}
+
+// Validation checks for a check-in. Only days of the current period up to
+// today can be checked in, since closed periods are final
+func ValidateCheckin(v *validator.Validator, checkin *Checkin, periodStart time.Time, today time.Time) {
+	v.Check(len(checkin.Note) <= 500, "note", "max_bytes", 500)
+	v.Check(!checkin.CheckinDate.After(today), "checkin_date", "in_future")
+	v.Check(!checkin.CheckinDate.Before(periodStart), "checkin_date", "outside_period")
+}
+
+type HabitModel struct {
+	DB *sql.DB
+}
+
+// InsertCheckin adds a check-in. Checking in twice on the same day returns
+// ErrDuplicateCheckin
+func (m HabitModel) InsertCheckin(checkin *Checkin) error {
+	query := `
+		INSERT INTO goal_checkins (goal_id, checkin_date, note)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (goal_id, checkin_date) DO NOTHING
+		RETURNING checkin_id, created_at`
+
+	args := []any{checkin.GoalID, checkin.CheckinDate.Format(DateLayout), checkin.Note}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&checkin.ID, &checkin.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrDuplicateCheckin
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordClosedPeriods stores the result of every period of a recurring goal
+// that has ended since the last recorded one. Periods follow the owner's
+// time zone and first day of the week, and stop at the target date when the
+// goal has one. They are scored against the goal's target count as passed
+// in, so callers pass the goal as stored before changing it
+func (m HabitModel) RecordClosedPeriods(goal *Goal, now time.Time) error {
+	if goal.GoalType != GoalTypeRecurring || goal.TargetCount == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var tz string
+	var firstDayOfWeek int
+	var lastStart sql.NullTime
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(u.time_zone, 'UTC'), COALESCE(u.first_day_of_week, 1),
+		       (SELECT MAX(r.period_start) FROM goal_period_results r WHERE r.goal_id = g.goal_id)
+		FROM goals g
+		LEFT JOIN users u ON u.id = g.user_id
+		WHERE g.goal_id = $1`, goal.ID).Scan(&tz, &firstDayOfWeek, &lastStart)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	var last *time.Time
+	if lastStart.Valid {
+		last = &lastStart.Time
+	}
+
+	starts, ends := ClosedPeriods(goal, last, loc, firstDayOfWeek, now)
+	if len(starts) == 0 {
+		return nil
+	}
+
+	// count the active days of every closed period in one pass
+	query := `
+		SELECT d.day::text
+		FROM goals g
+		LEFT JOIN users u ON u.id = g.user_id
+		CROSS JOIN LATERAL (` + habitActiveDays + `) d
+		WHERE g.goal_id = $1
+		AND d.day BETWEEN $2::date AND $3::date`
+
+	rows, err := m.DB.QueryContext(ctx, query, goal.ID, starts[0], ends[len(ends)-1])
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	counts := make([]int64, len(starts))
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return err
+		}
+		// the dates are all in the same layout so they compare as strings
+		for i := range starts {
+			if day >= starts[i] && day <= ends[i] {
+				counts[i]++
+				break
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	var completed, targets []int64
+	var succeeded []bool
+	for _, result := range SettlePeriods(starts, ends, counts, *goal.TargetCount) {
+		completed = append(completed, int64(result.CompletedCount))
+		targets = append(targets, int64(result.TargetCount))
+		succeeded = append(succeeded, result.Succeeded)
+	}
+
+	insert := `
+		INSERT INTO goal_period_results (goal_id, period_start, period_end, completed_count, target_count, succeeded)
+		SELECT $1, p.period_start::date, p.period_end::date, p.completed_count, p.target_count, p.succeeded
+		FROM unnest($2::text[], $3::text[], $4::int[], $5::int[], $6::boolean[])
+		     AS p(period_start, period_end, completed_count, target_count, succeeded)
+		ON CONFLICT (goal_id, period_start) DO NOTHING`
+
+	args := []any{goal.ID, pq.Array(starts), pq.Array(ends), pq.Array(completed), pq.Array(targets), pq.Array(succeeded)}
+	_, err = m.DB.ExecContext(ctx, insert, args...)
+	return err
+}
+
+// ClosedPeriods lists the first and last days of the goal's periods that
+// ended before now and after the period starting at lastStart, or since the
+// goal was created when lastStart is nil
+func ClosedPeriods(goal *Goal, lastStart *time.Time, loc *time.Location, firstDayOfWeek int, now time.Time) (starts []string, ends []string) {
+	start := PeriodStart(goal.CreatedAt.In(loc), goal.Period, firstDayOfWeek)
+	if lastStart != nil {
+		start = NextPeriodStart(PeriodStart(*lastStart, goal.Period, firstDayOfWeek), goal.Period)
+	}
+	current := PeriodStart(now.In(loc), goal.Period, firstDayOfWeek)
+
+	for ; start.Before(current); start = NextPeriodStart(start, goal.Period) {
+		if goal.TargetDate != nil && start.After(*goal.TargetDate) {
+			break
+		}
+		starts = append(starts, start.Format(DateLayout))
+		ends = append(ends, NextPeriodStart(start, goal.Period).AddDate(0, 0, -1).Format(DateLayout))
+	}
+	return starts, ends
+}
+
+// SettlePeriods scores closed periods with the given active day counts
+// against targetCount
+func SettlePeriods(starts []string, ends []string, counts []int64, targetCount int) []*PeriodResult {
+	results := make([]*PeriodResult, len(starts))
+	for i := range starts {
+		results[i] = &PeriodResult{
+			PeriodStart:    starts[i],
+			PeriodEnd:      ends[i],
+			CompletedCount: int(counts[i]),
+			TargetCount:    targetCount,
+			Succeeded:      int(counts[i]) >= targetCount,
+		}
+	}
+	return results
+}
+
+// History returns the recorded periods of a goal, newest first
+func (m HabitModel) History(goalID int64, filters Filters) ([]*PeriodResult, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), period_start::text, period_end::text, completed_count, target_count, succeeded
+		FROM goal_period_results
+		WHERE goal_id = $1
+		ORDER BY period_start DESC
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, goalID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	results := []*PeriodResult{}
+	for rows.Next() {
+		var result PeriodResult
+		err := rows.Scan(
+			&totalRecords,
+			&result.PeriodStart,
+			&result.PeriodEnd,
+			&result.CompletedCount,
+			&result.TargetCount,
+			&result.Succeeded,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		results = append(results, &result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return results, metadata, nil
+}
@@ -0,0 +1,380 @@
+// Filename: internal/data/jobs.go
+package data
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// Job statuses. A failed job goes back to pending with a later run_at until
+// it runs out of attempts and becomes dead
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+)
+
+// ErrLostLease is returned when a worker finishes a job that another worker
+// has claimed again since its lease ran out
+var ErrLostLease = errors.New("job lease lost")
+
+// Job kinds
+const (
+	JobSendEmail      = "send_email"
+	JobSendActivation = "send_activation_email"
+)
+
+// SendEmailPayload is the payload of a send_email job
+type SendEmailPayload struct {
+	Recipient string         `json:"recipient"`
+	Template  string         `json:"template"`
+	Locale    string         `json:"locale,omitempty"` // picks the translated template
+	Data      map[string]any `json:"data"`
+}
+
+// SendActivationPayload is the payload of a send_activation_email job. The
+// job creates the activation token itself, so the token is never stored in
+// the jobs table where the job API would show it
+type SendActivationPayload struct {
+	UserID int64  `json:"user_id"`
+	Locale string `json:"locale,omitempty"`
+}
+
+// UnmarshalJSON keeps the whole numbers in Data as int64 instead of float64,
+// so templates can still compare them with literals like {{if eq .daysLeft 1}}
+func (p *SendEmailPayload) UnmarshalJSON(b []byte) error {
+	type plain SendEmailPayload
+
+	var payload plain
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return err
+	}
+
+	for key, value := range payload.Data {
+		payload.Data[key] = jsonNumbers(value)
+	}
+	*p = SendEmailPayload(payload)
+	return nil
+}
+
+// jsonNumbers replaces the json.Numbers in a decoded value with int64, or
+// float64 when they have a fraction
+func jsonNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, item := range v {
+			v[key] = jsonNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = jsonNumbers(item)
+		}
+	}
+	return value
+}
+
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedAt    *time.Time      `json:"locked_at,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ValidateJobStatus checks a status filter, where empty means any status
+func ValidateJobStatus(v *validator.Validator, status string) {
+	v.Check(status == "" || validator.PermittedValue(status, JobPending, JobRunning, JobSucceeded, JobDead), "status", "one_of", "pending, running, succeeded, dead")
+}
+
+type JobModel struct {
+	DB *sql.DB
+}
+
+const jobColumns = `id, kind, payload, status, attempts, max_attempts, run_at, locked_at, last_error, created_at, updated_at`
+
+func scanJob(row rowScanner, job *Job, leading ...any) error {
+	dest := append(leading,
+		&job.ID,
+		&job.Kind,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.LockedAt,
+		&job.LastError,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	return row.Scan(dest...)
+}
+
+// Enqueue adds a job that is ready to run straight away
+func (m JobModel) Enqueue(kind string, payload any) (*Job, error) {
+	return m.EnqueueAt(kind, payload, time.Now())
+}
+
+// EnqueueAt adds a job that won't run before runAt
+func (m JobModel) EnqueueAt(kind string, payload any, runAt time.Time) (*Job, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO jobs (kind, payload, run_at)
+		VALUES ($1, $2, $3)
+		RETURNING ` + jobColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var job Job
+	err = scanJob(m.DB.QueryRowContext(ctx, query, kind, body, runAt), &job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Claim locks the next job that is due and marks it as running. A job left
+// running for longer than lease (say the process died) is claimed again if
+// it has attempts left. SKIP LOCKED lets any number of workers claim at the same time. It returns
+// ErrRecordNotFound when there is nothing to do
+func (m JobModel) Claim(lease time.Duration) (*Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE attempts < max_attempts
+			AND ((status = 'pending' AND run_at <= NOW())
+			OR (status = 'running' AND locked_at < NOW() - make_interval(secs => $1)))
+			ORDER BY run_at ASC, id ASC
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var job Job
+	err := scanJob(m.DB.QueryRowContext(ctx, query, lease.Seconds()), &job)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &job, nil
+}
+
+// Complete marks a claimed job as succeeded. Every claim counts an attempt,
+// so a job still running with the attempt it was claimed with is still
+// this worker's. Otherwise it returns ErrLostLease and the job is left to
+// whoever claimed it since
+func (m JobModel) Complete(job *Job) error {
+	query := `
+		UPDATE jobs
+		SET status = 'succeeded', locked_at = NULL, last_error = '', updated_at = NOW()
+		WHERE id = $1 AND status = 'running' AND attempts = $2
+		RETURNING status, updated_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, job.ID, job.Attempts).Scan(&job.Status, &job.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLostLease
+	}
+	return err
+}
+
+// Fail records why a claimed job failed. It runs again after the backoff,
+// unless it has used all of its attempts or retry is false, in which case
+// it is dead. Like Complete, it returns ErrLostLease when the job has been
+// claimed again
+func (m JobModel) Fail(job *Job, jobErr error, backoff time.Duration, retry bool) error {
+	query := `
+		UPDATE jobs
+		SET status = CASE WHEN $3 AND attempts < max_attempts THEN 'pending' ELSE 'dead' END,
+		    run_at = NOW() + make_interval(secs => $4),
+		    locked_at = NULL, last_error = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'running' AND attempts = $5
+		RETURNING status, run_at, updated_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := []any{job.ID, jobErr.Error(), retry, backoff.Seconds(), job.Attempts}
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&job.Status, &job.RunAt, &job.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLostLease
+	}
+	return err
+}
+
+// Bury marks the jobs that ran out of lease on their last attempt as dead,
+// since Claim won't take them again. It returns how many there were
+func (m JobModel) Bury(lease time.Duration) (int64, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'dead', locked_at = NULL, last_error = 'lease ran out on the last attempt', updated_at = NOW()
+		WHERE status = 'running' AND attempts >= max_attempts
+		AND locked_at < NOW() - make_interval(secs => $1)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, lease.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Prune deletes the jobs that succeeded longer than retention ago. Dead
+// jobs are kept until someone has looked at them. It returns how many were
+// deleted
+func (m JobModel) Prune(retention time.Duration) (int64, error) {
+	query := `
+		DELETE FROM jobs
+		WHERE status = 'succeeded' AND updated_at < NOW() - make_interval(secs => $1)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Get a specific job
+func (m JobModel) Get(id int64) (*Job, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var job Job
+	err := scanJob(m.DB.QueryRowContext(ctx, query, id), &job)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &job, nil
+}
+
+// GetAll lists the jobs, optionally only those with the given status or kind
+func (m JobModel) GetAll(status string, kind string, filters Filters) ([]*Job, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), ` + jobColumns + `
+		FROM jobs
+		WHERE (status = $1 OR $1 = '')
+		AND (kind = $2 OR $2 = '')
+		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, id ASC
+		LIMIT $3 OFFSET $4`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, status, kind, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	jobs := []*Job{}
+	for rows.Next() {
+		var job Job
+		err := scanJob(rows, &job, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		jobs = append(jobs, &job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return jobs, metadata, nil
+}
+
+// Counts returns how many jobs there are in each status
+func (m JobModel) Counts() (map[string]int, error) {
+	query := `
+		SELECT status, COUNT(*)
+		FROM jobs
+		GROUP BY status`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{JobPending: 0, JobRunning: 0, JobSucceeded: 0, JobDead: 0}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,266 @@
+// Filename: internal/data/milestones.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// Milestone is one ordered step of a goal. Position starts at 1
+type Milestone struct {
+	ID          int64      `json:"id"`
+	GoalID      int64      `json:"goal_id"`
+	Title       string     `json:"title"`
+	Position    int        `json:"position"`
+	DueDate     time.Time  `json:"due_date"`
+	IsCompleted bool       `json:"is_completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Validation checks for Milestone input
+func ValidateMilestone(v *validator.Validator, m *Milestone) {
+	v.Check(m.Title != "", "title", "required")
+	v.Check(len(m.Title) <= 255, "title", "max_bytes", 255)
+	v.Check(!m.DueDate.IsZero(), "due_date", "required")
+	v.Check(m.Position >= 0, "position", "not_negative")
+}
+
+type MilestoneModel struct {
+	DB *sql.DB
+}
+
+// Insert a new milestone. A position of 0 appends it after the existing
+// milestones, otherwise the milestones at or after that position move down
+func (m MilestoneModel) Insert(milestone *Milestone) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM goal_milestones WHERE goal_id = $1`, milestone.GoalID).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if milestone.Position == 0 || milestone.Position > count+1 {
+		milestone.Position = count + 1
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE goal_milestones
+			SET position = position + 1
+			WHERE goal_id = $1 AND position >= $2`, milestone.GoalID, milestone.Position)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO goal_milestones (goal_id, title, position, due_date, is_completed, completed_at)
+		VALUES ($1, $2, $3, $4, $5, CASE WHEN $5 THEN NOW() END)
+		RETURNING milestone_id, completed_at, created_at`
+
+	args := []any{milestone.GoalID, milestone.Title, milestone.Position, milestone.DueDate.Format(DateLayout), milestone.IsCompleted}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&milestone.ID, &milestone.CompletedAt, &milestone.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Get a milestone that belongs to the given goal
+func (m MilestoneModel) Get(goalID int64, id int64) (*Milestone, error) {
+	if goalID < 1 || id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT milestone_id, goal_id, title, position, due_date, is_completed, completed_at, created_at
+		FROM goal_milestones
+		WHERE goal_id = $1 AND milestone_id = $2`
+
+	var milestone Milestone
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, goalID, id).Scan(
+		&milestone.ID,
+		&milestone.GoalID,
+		&milestone.Title,
+		&milestone.Position,
+		&milestone.DueDate,
+		&milestone.IsCompleted,
+		&milestone.CompletedAt,
+		&milestone.CreatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &milestone, nil
+}
+
+// GetAllForGoal returns the milestones of a goal in order
+func (m MilestoneModel) GetAllForGoal(goalID int64) ([]*Milestone, error) {
+	query := `
+		SELECT milestone_id, goal_id, title, position, due_date, is_completed, completed_at, created_at
+		FROM goal_milestones
+		WHERE goal_id = $1
+		ORDER BY position ASC, milestone_id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	milestones := []*Milestone{}
+	for rows.Next() {
+		var milestone Milestone
+		err := rows.Scan(
+			&milestone.ID,
+			&milestone.GoalID,
+			&milestone.Title,
+			&milestone.Position,
+			&milestone.DueDate,
+			&milestone.IsCompleted,
+			&milestone.CompletedAt,
+			&milestone.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, &milestone)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return milestones, nil
+}
+
+// Update a milestone. When its position changes the milestones in between
+// shift up or down by one so the order stays contiguous
+func (m MilestoneModel) Update(milestone *Milestone) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldPosition, count int
+	err = tx.QueryRowContext(ctx, `
+		SELECT position, (SELECT COUNT(*) FROM goal_milestones WHERE goal_id = $1)
+		FROM goal_milestones
+		WHERE goal_id = $1 AND milestone_id = $2
+		FOR UPDATE`, milestone.GoalID, milestone.ID).Scan(&oldPosition, &count)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	if milestone.Position < 1 || milestone.Position > count {
+		milestone.Position = count
+	}
+
+	switch {
+	case milestone.Position < oldPosition:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE goal_milestones
+			SET position = position + 1
+			WHERE goal_id = $1 AND position >= $2 AND position < $3`, milestone.GoalID, milestone.Position, oldPosition)
+	case milestone.Position > oldPosition:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE goal_milestones
+			SET position = position - 1
+			WHERE goal_id = $1 AND position > $2 AND position <= $3`, milestone.GoalID, oldPosition, milestone.Position)
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE goal_milestones
+		SET title = $1, position = $2, due_date = $3, is_completed = $4,
+		    completed_at = CASE WHEN NOT $4 THEN NULL ELSE COALESCE(completed_at, NOW()) END
+		WHERE milestone_id = $5
+		RETURNING completed_at`
+
+	args := []any{milestone.Title, milestone.Position, milestone.DueDate.Format(DateLayout), milestone.IsCompleted, milestone.ID}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&milestone.CompletedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete a milestone and close the gap it leaves in the order
+func (m MilestoneModel) Delete(goalID int64, id int64) error {
+	if goalID < 1 || id < 1 {
+		return ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var position int
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM goal_milestones
+		WHERE goal_id = $1 AND milestone_id = $2
+		RETURNING position`, goalID, id).Scan(&position)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE goal_milestones
+		SET position = position - 1
+		WHERE goal_id = $1 AND position > $2`, goalID, position)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,264 @@
+// Filename: internal/data/notification_preferences.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// Notification channels and categories. Security notifications, such as
+// account activation, password resets and lockouts, are always emailed and
+// never kept in the in-app inbox, since they can carry tokens
+const (
+	ChannelEmail = "email"
+	ChannelInApp = "in_app"
+
+	CategoryReminders  = "reminders"
+	CategoryDigests    = "digests"
+	CategoryModeration = "moderation"
+	CategoryFollows    = "follows"
+	CategorySecurity   = "security"
+)
+
+var (
+	NotificationChannels   = []string{ChannelEmail, ChannelInApp}
+	NotificationCategories = []string{CategoryReminders, CategoryDigests, CategoryModeration, CategoryFollows, CategorySecurity}
+)
+
+// Times of day look like "07:00" or "22:30"
+var TimeOfDayRX = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// QuietHours is a daily window in the user's time zone when only security
+// notifications go out right away. The rest wait until it ends. An End
+// before Start wraps past midnight
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// NotificationPreferences holds, per channel, whether each category is
+// enabled, plus the quiet hours
+type NotificationPreferences struct {
+	Channels   map[string]map[string]bool `json:"channels"`
+	QuietHours *QuietHours                `json:"quiet_hours"`
+	TimeZone   string                     `json:"-"` // for the quiet hours
+}
+
+// DefaultNotificationPreferences has every category enabled and no quiet
+// hours
+func DefaultNotificationPreferences() *NotificationPreferences {
+	prefs := &NotificationPreferences{Channels: map[string]map[string]bool{}}
+	for _, channel := range NotificationChannels {
+		prefs.Channels[channel] = map[string]bool{}
+		for _, category := range NotificationCategories {
+			prefs.Channels[channel][category] = true
+		}
+	}
+	return prefs
+}
+
+// Enabled reports whether the user wants the category on the channel.
+// Security is always enabled
+func (p *NotificationPreferences) Enabled(channel, category string) bool {
+	if category == CategorySecurity {
+		return true
+	}
+	enabled, ok := p.Channels[channel][category]
+	return !ok || enabled
+}
+
+// QuietUntil returns when the quiet hours around now end, or the zero time
+// when now is outside them
+func (p *NotificationPreferences) QuietUntil(now time.Time) time.Time {
+	if p.QuietHours == nil {
+		return time.Time{}
+	}
+	start, err1 := time.Parse("15:04", p.QuietHours.Start)
+	end, err2 := time.Parse("15:04", p.QuietHours.End)
+	if err1 != nil || err2 != nil {
+		return time.Time{}
+	}
+
+	local := now.In(Preferences{TimeZone: p.TimeZone}.Location())
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	quiet := startMinute <= minute && minute < endMinute
+	if startMinute > endMinute {
+		quiet = minute >= startMinute || minute < endMinute
+	}
+	if !quiet {
+		return time.Time{}
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, local.Location())
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until
+}
+
+// ValidateNotificationPreferences checks the channels, categories and quiet
+// hours. Security can't be turned off
+func ValidateNotificationPreferences(v *validator.Validator, p *NotificationPreferences) {
+	for channel, categories := range p.Channels {
+		key := "channels." + channel
+		if !validator.PermittedValue(channel, NotificationChannels...) {
+			v.AddError(key, "unknown_channel")
+			continue
+		}
+		for category, enabled := range categories {
+			key := key + "." + category
+			v.Check(validator.PermittedValue(category, NotificationCategories...), key, "one_of", strings.Join(NotificationCategories, ", "))
+			v.Check(category != CategorySecurity || enabled, key, "mandatory_notification")
+		}
+	}
+
+	if p.QuietHours != nil {
+		v.Check(validator.Matches(p.QuietHours.Start, TimeOfDayRX), "quiet_hours.start", "invalid_time_of_day")
+		v.Check(validator.Matches(p.QuietHours.End, TimeOfDayRX), "quiet_hours.end", "invalid_time_of_day")
+		v.Check(p.QuietHours.Start != p.QuietHours.End, "quiet_hours.end", "same_as_start")
+	}
+}
+
+type NotificationPreferenceModel struct {
+	DB *sql.DB
+}
+
+// Get the user's notification preferences, filling in the defaults for
+// anything they haven't set
+func (m NotificationPreferenceModel) Get(userID int64) (*NotificationPreferences, error) {
+	prefs := DefaultNotificationPreferences()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var start, end sql.NullString
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT time_zone, to_char(quiet_hours_start, 'HH24:MI'), to_char(quiet_hours_end, 'HH24:MI')
+		FROM users
+		WHERE id = $1`, userID).Scan(&prefs.TimeZone, &start, &end)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	if start.Valid && end.Valid {
+		prefs.QuietHours = &QuietHours{Start: start.String, End: end.String}
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT channel, category, enabled
+		FROM notification_preferences
+		WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var channel, category string
+		var enabled bool
+		err := rows.Scan(&channel, &category, &enabled)
+		if err != nil {
+			return nil, err
+		}
+		if prefs.Channels[channel] != nil {
+			prefs.Channels[channel][category] = enabled
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// Update saves the categories in prefs and replaces the quiet hours
+func (m NotificationPreferenceModel) Update(userID int64, prefs *NotificationPreferences) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for channel, categories := range prefs.Channels {
+		for category, enabled := range categories {
+			if category == CategorySecurity {
+				continue
+			}
+			_, err = tx.ExecContext(ctx, upsertNotificationPreference, userID, channel, category, enabled)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var start, end any
+	if prefs.QuietHours != nil {
+		start, end = prefs.QuietHours.Start, prefs.QuietHours.End
+	}
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET quiet_hours_start = $1::time, quiet_hours_end = $2::time
+		WHERE id = $3`, start, end, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return tx.Commit()
+}
+
+// SetEnabled turns one category on or off, as the unsubscribe links do
+func (m NotificationPreferenceModel) SetEnabled(userID int64, channel, category string, enabled bool) error {
+	if category == CategorySecurity {
+		return fmt.Errorf("the %s category can't be changed", category)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, upsertNotificationPreference, userID, channel, category, enabled)
+	return err
+}
+
+const upsertNotificationPreference = `
+		INSERT INTO notification_preferences (user_id, channel, category, enabled)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, channel, category)
+		DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()`
+
+// anyChannelEnabled is a filter for queries that join the recipient as u,
+// keeping users who have the category on for at least one channel. The
+// category is one of the constants above, never user input
+func anyChannelEnabled(category string) string {
+	return `EXISTS (
+			SELECT 1 FROM unnest(ARRAY['` + strings.Join(NotificationChannels, "', '") + `']) AS c(channel)
+			WHERE NOT EXISTS (
+				SELECT 1 FROM notification_preferences np
+				WHERE np.user_id = u.id AND np.channel = c.channel AND np.category = '` + category + `' AND NOT np.enabled
+			)
+		)`
+}
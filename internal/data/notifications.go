@@ -0,0 +1,163 @@
+// Filename: internal/data/notifications.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+	"github.com/lib/pq"
+)
+
+// Notification is an entry in a user's in-app inbox. Kind says what it is
+// about (goal_due_soon, session_reminder, ...) and Data holds the IDs and
+// values the app needs to show it and link to it
+type Notification struct {
+	ID        int64           `json:"id"`
+	UserID    int64           `json:"-"`
+	Category  string          `json:"category"`
+	Kind      string          `json:"kind"`
+	Title     string          `json:"title"`
+	Data      json.RawMessage `json:"data"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ValidateNotificationIDs checks the notifications to mark as read
+func ValidateNotificationIDs(v *validator.Validator, ids []int64) {
+	v.Check(len(ids) > 0, "ids", "required")
+	v.Check(len(ids) <= 100, "ids", "max_items", 100)
+	for _, id := range ids {
+		if id < 1 {
+			v.AddError("ids", "greater_than_zero")
+			break
+		}
+	}
+}
+
+type NotificationModel struct {
+	DB *sql.DB
+}
+
+const notificationColumns = `id, user_id, category, kind, title, data, read_at, created_at`
+
+func scanNotification(row rowScanner, n *Notification, leading ...any) error {
+	dest := append(leading,
+		&n.ID,
+		&n.UserID,
+		&n.Category,
+		&n.Kind,
+		&n.Title,
+		&n.Data,
+		&n.ReadAt,
+		&n.CreatedAt,
+	)
+	return row.Scan(dest...)
+}
+
+// Insert adds a notification to the user's inbox
+func (m NotificationModel) Insert(n *Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, category, kind, title, data)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	if n.Data == nil {
+		n.Data = json.RawMessage(`{}`)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, n.UserID, n.Category, n.Kind, n.Title, []byte(n.Data)).Scan(&n.ID, &n.CreatedAt)
+}
+
+// GetAllForUser lists the user's inbox, optionally only what is unread
+func (m NotificationModel) GetAllForUser(userID int64, unreadOnly bool, filters Filters) ([]*Notification, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1
+		AND (read_at IS NULL OR NOT $2)
+		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, id DESC
+		LIMIT $3 OFFSET $4`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, unreadOnly, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	notifications := []*Notification{}
+	for rows.Next() {
+		var n Notification
+		err := scanNotification(rows, &n, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		notifications = append(notifications, &n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return notifications, metadata, nil
+}
+
+// MarkRead marks the user's notifications with the given IDs as read. IDs
+// that are someone else's or already read are ignored. It returns how many
+// were marked
+func (m NotificationModel) MarkRead(userID int64, ids []int64) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = NOW()
+		WHERE user_id = $1 AND id = ANY($2) AND read_at IS NULL`
+
+	return m.markRead(query, userID, pq.Array(ids))
+}
+
+// MarkAllRead marks everything in the user's inbox as read
+func (m NotificationModel) MarkAllRead(userID int64) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = NOW()
+		WHERE user_id = $1 AND read_at IS NULL`
+
+	return m.markRead(query, userID)
+}
+
+func (m NotificationModel) markRead(query string, args ...any) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// UnreadCount is the number on the bell icon
+func (m NotificationModel) UnreadCount(userID int64) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM notifications
+		WHERE user_id = $1 AND read_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,44 @@
+// Filename: internal/data/quote_hides.go
+package data
+
+import (
+	"context"
+	"time"
+)
+
+// Hide keeps the quote out of the user's random picks and daily quotes.
+// Hiding it again does nothing
+func (q QuoteModel) Hide(userID int64, quoteID int64) error {
+	query := `
+		INSERT INTO quote_hides (user_id, quote_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := q.DB.ExecContext(ctx, query, userID, quoteID)
+	return err
+}
+
+// Unhide lets the quote be picked for the user again
+func (q QuoteModel) Unhide(userID int64, quoteID int64) error {
+	query := `
+		DELETE FROM quote_hides
+		WHERE user_id = $1 AND quote_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := q.DB.ExecContext(ctx, query, userID, quoteID)
+	return err
+}
+
+// notHiddenFrom is a filter for queries on quotes q. The user's ID is the
+// given parameter, e.g. notHiddenFrom("$1")
+func notHiddenFrom(userParam string) string {
+	return `NOT EXISTS (
+			SELECT 1 FROM quote_hides h
+			WHERE h.user_id = ` + userParam + ` AND h.quote_id = q.quote_id
+		)`
+}
@@ -0,0 +1,124 @@
+// Filename: internal/data/quote_import.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// The most rows one import can have
+const MaxImportRows = 1000
+
+// ImportResult is what happened to one imported quote. DuplicateOf is set
+// instead of the quote being inserted when a quote with nearly the same
+// content exists
+type ImportResult struct {
+	QuoteID     int64
+	DuplicateOf int64
+}
+
+// Import inserts the quotes in one transaction, skipping those whose
+// content only differs in case, punctuation or spacing from a quote the
+// user can see or from an earlier one in the import. With dryRun nothing is
+// kept, but the results are the same
+func (q QuoteModel) Import(userID int64, quotes []*Quote, dryRun bool) ([]ImportResult, error) {
+	query := `
+		SELECT q.quote_id
+		FROM quotes q
+		WHERE q.content_key = normalize_quote($1)
+		AND ` + visibleTo("$2", "false") + `
+		ORDER BY q.quote_id ASC
+		LIMIT 1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := q.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]ImportResult, len(quotes))
+	for i, quote := range quotes {
+		// quotes inserted earlier in the transaction are found here too
+		var duplicateOf int64
+		err := tx.QueryRowContext(ctx, query, quote.Content, userID).Scan(&duplicateOf)
+		switch {
+		case err == nil:
+			results[i].DuplicateOf = duplicateOf
+			continue
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, err
+		}
+
+		err = insertQuote(ctx, tx, quote)
+		if err != nil {
+			return nil, err
+		}
+		results[i].QuoteID = quote.ID
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	return results, tx.Commit()
+}
+
+// Export calls fn with each quote the viewer can see that matches search,
+// oldest first, while reading them from the database. ownOnly limits it to
+// the viewer's own quotes
+func (q QuoteModel) Export(viewerID int64, ownOnly bool, search QuoteSearch, fn func(*Quote) error) error {
+	query := `
+		SELECT q.quote_id, q.user_id, COALESCE(u.username, ''), q.content, q.likes_count,
+		       q.status, q.visibility, q.moderation_reason, q.author, q.source, ` + quoteTagsColumn + `, q.created_at
+		FROM quotes q
+		LEFT JOIN users u ON q.user_id = u.id
+		WHERE (q.user_id = $1 OR (NOT $2 AND ` + visibleTo("$1", "false") + `))
+		AND ` + fuzzyMatch("q.content", "$3", fuzzyTextThreshold) + `
+		AND ` + hasTags("$4") + `
+		AND (lower(q.author) = lower($5) OR $5 = '')
+		AND (u.username = $6 OR $6 = '')
+		ORDER BY q.quote_id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	args := []any{viewerID, ownOnly, search.Content, pq.Array(search.Tags), search.Author, search.SubmittedBy}
+	rows, done, err := fuzzyQuery(ctx, q.DB, query, args...)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	for rows.Next() {
+		var quote Quote
+		err := rows.Scan(
+			&quote.ID,
+			&quote.UserID,
+			&quote.Username,
+			&quote.Content,
+			&quote.Likes,
+			&quote.Status,
+			&quote.Visibility,
+			&quote.ModerationReason,
+			&quote.Author,
+			&quote.Source,
+			pq.Array(&quote.Tags),
+			&quote.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if err := fn(&quote); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,60 @@
+package data
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestImport_Duplicates(t *testing.T) {
+	db := newTestDB(t)
+	quotes := QuoteModel{DB: db}
+
+	alice := insertTestUser(t, db, "alice")
+	bob := insertTestUser(t, db, "bob")
+	existing := insertTestQuote(t, db, bob.ID, "Keep going.", QuoteApproved, VisibilityPublic)
+	insertTestQuote(t, db, bob.ID, "Private thought", QuoteApproved, VisibilityPrivate)
+
+	imported := func(content string) *Quote {
+		return &Quote{UserID: alice.ID, Content: content, Status: QuotePending, Visibility: VisibilityPublic}
+	}
+	results, err := quotes.Import(alice.ID, []*Quote{
+		imported("KEEP   going!"),
+		imported("Private thought"),
+		imported("private thought?"),
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first only differs from bob's public quote in case, punctuation
+	// and spacing. bob's private quote can't be seen, so the same text is
+	// new, and the last one repeats it
+	if results[0].DuplicateOf != existing.ID || results[0].QuoteID != 0 {
+		t.Errorf("first result = %+v; want a duplicate of %d", results[0], existing.ID)
+	}
+	if results[1].QuoteID == 0 || results[1].DuplicateOf != 0 {
+		t.Errorf("second result = %+v; want it inserted", results[1])
+	}
+	if results[2].DuplicateOf != results[1].QuoteID {
+		t.Errorf("third result = %+v; want a duplicate of %d", results[2], results[1].QuoteID)
+	}
+}
+
+func TestImport_DryRun(t *testing.T) {
+	db := newTestDB(t)
+	quotes := QuoteModel{DB: db}
+
+	alice := insertTestUser(t, db, "alice")
+	quote := &Quote{UserID: alice.ID, Content: "Only a test", Status: QuotePending, Visibility: VisibilityPublic}
+	results, err := quotes.Import(alice.ID, []*Quote{quote}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].QuoteID == 0 {
+		t.Fatalf("result = %+v; want the ID it would get", results[0])
+	}
+
+	if _, err := quotes.GetByID(results[0].QuoteID, alice.ID, false); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("GetByID() error = %v; want the dry run to keep nothing", err)
+	}
+}
@@ -0,0 +1,224 @@
+// Filename: internal/data/quote_moderation.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+	"github.com/lib/pq"
+)
+
+// Moderation statuses. Only approved quotes are shown to users other than
+// their author
+const (
+	QuotePending  = "pending"
+	QuoteApproved = "approved"
+	QuoteRejected = "rejected"
+)
+
+// The reason given when reports send a quote back to the queue
+const ReasonReported = "reported"
+
+var QuoteStatuses = []string{QuotePending, QuoteApproved, QuoteRejected}
+
+// ValidateModeration checks a moderator's decision. Rejections need a
+// reason so the author knows what to change
+func ValidateModeration(v *validator.Validator, status string, reason string) {
+	v.Check(status != "", "status", "required")
+	v.Check(status == "" || validator.PermittedValue(status, QuoteStatuses...), "status", "one_of", "pending, approved, rejected")
+	v.Check(status != QuoteRejected || reason != "", "reason", "required")
+	v.Check(len(reason) <= 500, "reason", "max_bytes", 500)
+}
+
+// ValidateQuoteStatus checks the status the moderation queue is filtered by
+func ValidateQuoteStatus(v *validator.Validator, status string) {
+	v.Check(validator.PermittedValue(status, QuoteStatuses...), "status", "one_of", "pending, approved, rejected")
+}
+
+// ValidateReportReason checks the reason given with a report, which is
+// optional
+func ValidateReportReason(v *validator.Validator, reason string) {
+	v.Check(len(reason) <= 500, "reason", "max_bytes", 500)
+}
+
+// ModerationQueue lists the quotes with the given status, along with how
+// many unresolved reports each has. reports sorts by that count. Private
+// quotes are never listed
+func (q QuoteModel) ModerationQueue(status string, filters Filters) ([]*Quote, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), q.quote_id AS id, q.user_id, COALESCE(u.username, ''), q.content,
+		       q.likes_count AS popular, q.status, q.visibility, q.moderation_reason, q.author, q.source,
+		       ` + quoteTagsColumn + `, q.created_at,
+		       (SELECT COUNT(*) FROM quote_reports r WHERE r.quote_id = q.quote_id AND NOT r.resolved) AS reports
+		FROM quotes q
+		LEFT JOIN users u ON q.user_id = u.id
+		WHERE q.status = $1 AND q.visibility <> 'private'
+		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, id ASC
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := q.DB.QueryContext(ctx, query, status, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	quotes := []*Quote{}
+	for rows.Next() {
+		var quote Quote
+		err := rows.Scan(
+			&totalRecords,
+			&quote.ID,
+			&quote.UserID,
+			&quote.Username,
+			&quote.Content,
+			&quote.Likes,
+			&quote.Status,
+			&quote.Visibility,
+			&quote.ModerationReason,
+			&quote.Author,
+			&quote.Source,
+			pq.Array(&quote.Tags),
+			&quote.CreatedAt,
+			&quote.Reports,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		quotes = append(quotes, &quote)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return quotes, metadata, nil
+}
+
+// Moderate records a moderator's decision on the quote and resolves its
+// reports
+func (q QuoteModel) Moderate(quoteID int64, moderatorID int64, status string, reason string) (*Quote, error) {
+	if quoteID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := q.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE quotes q
+		SET status = $1, moderation_reason = $2, moderated_by = $3, moderated_at = NOW()
+		WHERE q.quote_id = $4
+		RETURNING q.quote_id, q.user_id, q.content, q.likes_count, q.status, q.visibility, q.moderation_reason, q.author, q.source,
+		          ` + quoteTagsColumn + `, q.created_at`
+
+	var quote Quote
+	err = tx.QueryRowContext(ctx, query, status, reason, moderatorID, quoteID).Scan(
+		&quote.ID,
+		&quote.UserID,
+		&quote.Content,
+		&quote.Likes,
+		&quote.Status,
+		&quote.Visibility,
+		&quote.ModerationReason,
+		&quote.Author,
+		&quote.Source,
+		pq.Array(&quote.Tags),
+		&quote.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE quote_reports
+		SET resolved = true
+		WHERE quote_id = $1 AND NOT resolved`, quoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quote, tx.Commit()
+}
+
+// Report records the user's report of the quote. Reporting it again
+// replaces the reason, and reopens the report if a moderator resolved it.
+// Once the quote has threshold unresolved reports and is approved, it goes
+// back to the moderation queue, which Report returns true for
+func (q QuoteModel) Report(userID int64, quoteID int64, reason string, threshold int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := q.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// lock the quote so two reports can't both miss the threshold
+	var status string
+	err = tx.QueryRowContext(ctx, `
+		SELECT status
+		FROM quotes
+		WHERE quote_id = $1
+		FOR UPDATE`, quoteID).Scan(&status)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, ErrRecordNotFound
+		default:
+			return false, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO quote_reports (user_id, quote_id, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, quote_id) DO UPDATE
+		SET reason = EXCLUDED.reason, resolved = false, created_at = NOW()`,
+		userID, quoteID, reason)
+	if err != nil {
+		return false, err
+	}
+
+	var reports int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM quote_reports
+		WHERE quote_id = $1 AND NOT resolved`, quoteID).Scan(&reports)
+	if err != nil {
+		return false, err
+	}
+
+	queued := status == QuoteApproved && reports >= threshold
+	if queued {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE quotes
+			SET status = $1, moderation_reason = $2, moderated_by = NULL, moderated_at = NOW()
+			WHERE quote_id = $3`, QuotePending, ReasonReported, quoteID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return queued, tx.Commit()
+}
@@ -0,0 +1,96 @@
+// Filename: internal/data/quote_reactions.go
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Favorite saves the quote to the user's favorites, which counts as a like.
+// Favoriting it again does nothing
+func (q QuoteModel) Favorite(userID int64, quoteID int64) error {
+	query := `
+		INSERT INTO quote_reactions (user_id, quote_id, kind)
+		VALUES ($1, $2, 'favorite')
+		ON CONFLICT DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := q.DB.ExecContext(ctx, query, userID, quoteID)
+	return err
+}
+
+// Unfavorite removes the quote from the user's favorites
+func (q QuoteModel) Unfavorite(userID int64, quoteID int64) error {
+	query := `
+		DELETE FROM quote_reactions
+		WHERE user_id = $1 AND quote_id = $2 AND kind = 'favorite'`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := q.DB.ExecContext(ctx, query, userID, quoteID)
+	return err
+}
+
+// FavoritesForUser lists the quotes the user favorited. favorited_at sorts
+// by when they did. Quotes the user can no longer see are left out
+func (q QuoteModel) FavoritesForUser(userID int64, filters Filters) ([]*Quote, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER(), q.quote_id AS id, q.user_id, COALESCE(u.username, ''), q.content,
+		       q.likes_count AS popular, q.status, q.visibility, q.moderation_reason, q.author, q.source,
+		       ` + quoteTagsColumn + `, q.created_at, r.created_at AS favorited_at
+		FROM quote_reactions r
+		JOIN quotes q ON q.quote_id = r.quote_id
+		LEFT JOIN users u ON q.user_id = u.id
+		WHERE r.user_id = $1 AND r.kind = 'favorite'
+		AND ` + visibleTo("$1", "false") + `
+		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, id ASC
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := q.DB.QueryContext(ctx, query, userID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	quotes := []*Quote{}
+	for rows.Next() {
+		var quote Quote
+		err := rows.Scan(
+			&totalRecords,
+			&quote.ID,
+			&quote.UserID,
+			&quote.Username,
+			&quote.Content,
+			&quote.Likes,
+			&quote.Status,
+			&quote.Visibility,
+			&quote.ModerationReason,
+			&quote.Author,
+			&quote.Source,
+			pq.Array(&quote.Tags),
+			&quote.CreatedAt,
+			&quote.FavoritedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		quotes = append(quotes, &quote)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return quotes, metadata, nil
+}
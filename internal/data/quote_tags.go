@@ -0,0 +1,127 @@
+// Filename: internal/data/quote_tags.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+	"github.com/lib/pq"
+)
+
+// The most tags a quote can have
+const MaxQuoteTags = 10
+
+// Tags look like "motivation" or "exam-prep"
+var TagRX = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,29}$`)
+
+// quoteTagsColumn selects the tags of the quote q, sorted by name
+const quoteTagsColumn = `ARRAY(
+			SELECT t.name
+			FROM quote_tags qt
+			JOIN tags t ON t.tag_id = qt.tag_id
+			WHERE qt.quote_id = q.quote_id
+			ORDER BY t.name
+		) AS tags`
+
+// Tag is a tag and how many quotes use it
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// ValidateTags checks a quote's tags, or those a list is filtered by, once
+// NormalizeTags has been applied
+func ValidateTags(v *validator.Validator, key string, tags []string) {
+	v.Check(len(tags) <= MaxQuoteTags, key, "max_items", MaxQuoteTags)
+	for _, tag := range tags {
+		if !validator.Matches(tag, TagRX) {
+			v.AddError(key, "invalid_tag")
+			return
+		}
+	}
+}
+
+// setQuoteTags replaces the quote's tags, creating the tags that are new
+func setQuoteTags(ctx context.Context, tx *sql.Tx, quoteID int64, tags []string) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM quote_tags
+		WHERE quote_id = $1`, quoteID)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tags (name)
+		SELECT unnest($1::text[])
+		ON CONFLICT (name) DO NOTHING`, pq.Array(tags))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO quote_tags (quote_id, tag_id)
+		SELECT $1, tag_id
+		FROM tags
+		WHERE name = ANY($2)`, quoteID, pq.Array(tags))
+	return err
+}
+
+// hasTags is a filter for queries on quotes q that keeps the quotes with
+// every tag in the given text[] parameter. An empty array keeps them all
+func hasTags(tagsParam string) string {
+	return `(cardinality(` + tagsParam + `::text[]) = 0 OR (
+			SELECT COUNT(*)
+			FROM quote_tags qt
+			JOIN tags t ON t.tag_id = qt.tag_id
+			WHERE qt.quote_id = q.quote_id AND t.name = ANY(` + tagsParam + `::text[])
+		) = cardinality(` + tagsParam + `::text[]))`
+}
+
+// Tags lists the tags in use with how many quotes the viewer can see have
+// each. count sorts by that
+func (q QuoteModel) Tags(viewerID int64, filters Filters) ([]*Tag, Metadata, error) {
+	query := `
+		SELECT COUNT(*) OVER() AS total_records, t.name, COUNT(*) AS count
+		FROM tags t
+		JOIN quote_tags qt ON qt.tag_id = t.tag_id
+		JOIN quotes q ON q.quote_id = qt.quote_id
+		WHERE ` + visibleTo("$1", "false") + `
+		GROUP BY t.tag_id, t.name
+		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, t.name ASC
+		LIMIT $2 OFFSET $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := q.DB.QueryContext(ctx, query, viewerID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	tags := []*Tag{}
+	for rows.Next() {
+		var tag Tag
+		err := rows.Scan(&totalRecords, &tag.Name, &tag.Count)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		tags = append(tags, &tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return tags, metadata, nil
+}
@@ -0,0 +1,35 @@
+// Filename: internal/data/quote_visibility.go
+package data
+
+import "github.com/aiycoleman/Study-Mate/internal/validator"
+
+// Who can see a quote besides its author. Followers-only quotes are shown to
+// the followers the author approved, and private ones to nobody else, not
+// even moderators
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityPrivate   = "private"
+)
+
+var QuoteVisibilities = []string{VisibilityPublic, VisibilityFollowers, VisibilityPrivate}
+
+// ValidateVisibility checks a quote's visibility
+func ValidateVisibility(v *validator.Validator, visibility string) {
+	v.Check(validator.PermittedValue(visibility, QuoteVisibilities...), "visibility", "one_of", "public, followers, private")
+}
+
+// visibleTo is a filter for queries on quotes q that keeps the quotes the
+// viewer in the given parameter can see. Apart from their own quotes, they
+// see approved ones that are public, or followers-only by someone who
+// approved them as a follower. When the moderator expression is true they also see those that
+// aren't approved, as long as they aren't private
+func visibleTo(viewerParam string, moderatorParam string) string {
+	return `(q.user_id = ` + viewerParam + `
+			OR (` + moderatorParam + ` AND q.visibility <> 'private')
+			OR (q.status = 'approved' AND (q.visibility = 'public' OR (q.visibility = 'followers' AND EXISTS (
+				SELECT 1
+				FROM follows f
+				WHERE f.follower_id = ` + viewerParam + ` AND f.followee_id = q.user_id AND f.approved
+			)))))`
+}
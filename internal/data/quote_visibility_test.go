@@ -0,0 +1,75 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVisibleTo_UsesTheGivenParams(t *testing.T) {
+	filter := visibleTo("$7", "$8::boolean")
+
+	if strings.Contains(filter, "$1") || strings.Count(filter, "$7") != 2 || !strings.Contains(filter, "$8::boolean") {
+		t.Errorf("visibleTo(\"$7\", \"$8::boolean\") = %s; want only those params", filter)
+	}
+	if !strings.Contains(filter, "f.approved") {
+		t.Errorf("visibleTo() = %s; want followers-only quotes limited to approved followers", filter)
+	}
+}
+
+func TestVisibleTo(t *testing.T) {
+	db := newTestDB(t)
+	quotes := QuoteModel{DB: db}
+	follows := FollowModel{DB: db}
+
+	alice := insertTestUser(t, db, "alice")
+	follower := insertTestUser(t, db, "bob")
+	requested := insertTestUser(t, db, "carol")
+	stranger := insertTestUser(t, db, "dave")
+
+	if _, err := follows.Follow(follower.ID, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := follows.Approve(follower.ID, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := follows.Follow(requested.ID, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	public := insertTestQuote(t, db, alice.ID, "Public", QuoteApproved, VisibilityPublic)
+	followers := insertTestQuote(t, db, alice.ID, "Followers only", QuoteApproved, VisibilityFollowers)
+	private := insertTestQuote(t, db, alice.ID, "Private", QuoteApproved, VisibilityPrivate)
+	pending := insertTestQuote(t, db, alice.ID, "Pending", QuotePending, VisibilityPublic)
+
+	tests := []struct {
+		name      string
+		viewer    *User
+		moderator bool
+		visible   []*Quote
+	}{
+		{"owner", alice, false, []*Quote{public, followers, private, pending}},
+		{"approved follower", follower, false, []*Quote{public, followers}},
+		{"follow request", requested, false, []*Quote{public}},
+		{"stranger", stranger, false, []*Quote{public}},
+		{"moderator", stranger, true, []*Quote{public, followers, pending}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, quote := range []*Quote{public, followers, private, pending} {
+				want := false
+				for _, v := range tt.visible {
+					want = want || v.ID == quote.ID
+				}
+
+				_, err := quotes.GetByID(quote.ID, tt.viewer.ID, tt.moderator)
+				if err != nil && !errors.Is(err, ErrRecordNotFound) {
+					t.Fatal(err)
+				}
+				if got := err == nil; got != want {
+					t.Errorf("%q visible = %t; want %t", quote.Content, got, want)
+				}
+			}
+		})
+	}
+}
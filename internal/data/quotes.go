@@ -8,40 +8,75 @@ import (
 	"time"
 
 	"github.com/aiycoleman/Study-Mate/internal/validator"
+	"github.com/lib/pq"
 )
 
 type Quote struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	UserID    int64     `json:"user_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int64     `json:"id"`
+	Username   string    `json:"username"`
+	UserID     int64     `json:"user_id"`
+	Content    string    `json:"content"`
+	Author     string    `json:"author"` // who said it, as opposed to who submitted it
+	Source     string    `json:"source"`
+	Tags       []string  `json:"tags"`
+	Likes      int       `json:"likes"`
+	Status     string    `json:"status"`
+	Visibility string    `json:"visibility"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	ModerationReason string     `json:"moderation_reason,omitempty"`
+	FavoritedAt      *time.Time `json:"favorited_at,omitempty"` // only in the user's favorites
+	Reports          int        `json:"reports,omitempty"`      // only in the moderation queue
 }
 
 // Performs validation checks for Quote input
 func ValidateQuote(v *validator.Validator, quote *Quote) {
-	v.Check(quote.Content != "", "content", "must be provided")
-	v.Check(len(quote.Content) <= 500, "content", "must not be more than 500 bytes long")
-	v.Check(quote.UserID > 0, "user_id", "must be a valid user ID")
+	v.Check(quote.Content != "", "content", "required")
+	v.Check(len(quote.Content) <= 500, "content", "max_bytes", 500)
+	v.Check(len(quote.Author) <= 200, "author", "max_bytes", 200)
+	v.Check(len(quote.Source) <= 200, "source", "max_bytes", 200)
+	ValidateTags(v, "tags", quote.Tags)
+	ValidateVisibility(v, quote.Visibility)
+	v.Check(quote.UserID > 0, "user_id", "invalid_user_id")
 }
 
 type QuoteModel struct {
 	DB *sql.DB
 }
 
-// Insert a new quote into the database
+// Insert a new quote, along with its tags, into the database
 func (q QuoteModel) Insert(quote *Quote) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := q.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = insertQuote(ctx, tx, quote)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertQuote(ctx context.Context, tx *sql.Tx, quote *Quote) error {
 	query := `
-		INSERT INTO quotes (user_id, content)
-		VALUES ($1, $2)
+		INSERT INTO quotes (user_id, content, author, source, status, visibility)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING quote_id, created_at`
 
-	args := []any{quote.UserID, quote.Content}
+	args := []any{quote.UserID, quote.Content, quote.Author, quote.Source, quote.Status, quote.Visibility}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&quote.ID, &quote.CreatedAt)
+	if err != nil {
+		return err
+	}
 
-	return q.DB.QueryRowContext(ctx, query, args...).Scan(&quote.ID, &quote.CreatedAt)
+	return setQuoteTags(ctx, tx, quote.ID, quote.Tags)
 }
 
 // Get a specific quote from the database
@@ -51,9 +86,10 @@ func (q QuoteModel) Get(id int64) (*Quote, error) {
 	}
 
 	query := `
-		SELECT quote_id, user_id, content, created_at
-		FROM quotes
-		WHERE quote_id = $1`
+		SELECT q.quote_id, q.user_id, q.content, q.likes_count, q.status, q.visibility, q.moderation_reason, q.author, q.source,
+		       ` + quoteTagsColumn + `, q.created_at
+		FROM quotes q
+		WHERE q.quote_id = $1`
 
 	var quote Quote
 
@@ -64,6 +100,13 @@ func (q QuoteModel) Get(id int64) (*Quote, error) {
 		&quote.ID,
 		&quote.UserID,
 		&quote.Content,
+		&quote.Likes,
+		&quote.Status,
+		&quote.Visibility,
+		&quote.ModerationReason,
+		&quote.Author,
+		&quote.Source,
+		pq.Array(&quote.Tags),
 		&quote.CreatedAt,
 	)
 
@@ -79,41 +122,67 @@ func (q QuoteModel) Get(id int64) (*Quote, error) {
 	return &quote, nil
 }
 
-// Update a specific quote
+// Update a specific quote and replace its tags
 func (q QuoteModel) Update(quote *Quote) error {
 	query := `
-		UPDATE quotes
-		SET content = $1
-		WHERE quote_id = $2
-		RETURNING quote_id, user_id, content, created_at`
+		UPDATE quotes q
+		SET content = $1, author = $2, source = $3, status = $4, visibility = $5
+		WHERE q.quote_id = $6
+		RETURNING q.quote_id, q.user_id, q.content, q.likes_count, q.status, q.visibility, q.moderation_reason, q.author, q.source,
+		          ` + quoteTagsColumn + `, q.created_at`
 
-	args := []any{quote.Content, quote.ID}
+	args := []any{quote.Content, quote.Author, quote.Source, quote.Status, quote.Visibility, quote.ID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return q.DB.QueryRowContext(ctx, query, args...).Scan(
+	tx, err := q.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the tags go first so the RETURNING clause sees them
+	err = setQuoteTags(ctx, tx, quote.ID, quote.Tags)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(
 		&quote.ID,
 		&quote.UserID,
 		&quote.Content,
+		&quote.Likes,
+		&quote.Status,
+		&quote.Visibility,
+		&quote.ModerationReason,
+		&quote.Author,
+		&quote.Source,
+		pq.Array(&quote.Tags),
 		&quote.CreatedAt,
 	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// Delete a specific quote
-func (q QuoteModel) Delete(id int64) error {
+// Delete a specific quote of the user. Other users' quotes are not found
+func (q QuoteModel) Delete(id int64, userID int64) error {
 	if id < 1 {
 		return ErrRecordNotFound
 	}
 
 	query := `
 		DELETE FROM quotes
-		WHERE quote_id = $1`
+		WHERE quote_id = $1
+		AND user_id = $2`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	result, err := q.DB.ExecContext(ctx, query, id)
+	result, err := q.DB.ExecContext(ctx, query, id, userID)
 	if err != nil {
 		return err
 	}
@@ -130,25 +199,46 @@ func (q QuoteModel) Delete(id int64) error {
 	return nil
 }
 
+// QuoteSearch narrows down a list of quotes. A quote needs every one of
+// Tags, Author is matched regardless of case and SubmittedBy is a username.
+// Empty fields match everything
+type QuoteSearch struct {
+	Content     string
+	Tags        []string
+	Author      string
+	SubmittedBy string
+}
+
+// ValidateQuoteSearch checks the search parameters
+func ValidateQuoteSearch(v *validator.Validator, search QuoteSearch) {
+	ValidateTags(v, "tag", search.Tags)
+	v.Check(len(search.Author) <= 200, "author", "max_bytes", 200)
+	v.Check(len(search.SubmittedBy) <= 200, "submitted_by", "max_bytes", 200)
+}
+
 // GetAllForUser quotes for a specific user
-func (q QuoteModel) GetAllForUser(userID int64, content string, filters Filters) ([]*Quote, Metadata, error) {
+func (q QuoteModel) GetAllForUser(userID int64, search QuoteSearch, filters Filters) ([]*Quote, Metadata, error) {
     query := `
-       SELECT COUNT(*) OVER(), q.quote_id, q.user_id, u.username, q.content, q.created_at
+       SELECT COUNT(*) OVER(), q.quote_id AS id, q.user_id, u.username, q.content, q.likes_count AS popular,
+              q.status, q.visibility, q.moderation_reason, q.author, q.source, ` + quoteTagsColumn + `, q.created_at
        FROM quotes q
        JOIN users u ON q.user_id = u.id
        WHERE q.user_id = $1
-       AND (to_tsvector('simple', q.content) @@ plainto_tsquery('simple', $2) OR $2 = '')
-       ORDER BY  + filters.sortColumn() +   + filters.sortDirection() + , quote_id ASC
-       LIMIT $3 OFFSET $4`
+       AND ` + fuzzyMatch("q.content", "$2", fuzzyTextThreshold) + `
+       AND ` + hasTags("$3") + `
+       AND (lower(q.author) = lower($4) OR $4 = '')
+       ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, id ASC
+       LIMIT $5 OFFSET $6`
 
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    rows, err := q.DB.QueryContext(ctx, query, userID, content, filters.limit(), filters.offset())
+    args := []any{userID, search.Content, pq.Array(search.Tags), search.Author, filters.limit(), filters.offset()}
+    rows, done, err := fuzzyQuery(ctx, q.DB, query, args...)
     if err != nil {
        return nil, Metadata{}, err
     }
-    defer rows.Close()
+    defer done()
 
     totalRecords := 0
     var quotes []*Quote
@@ -161,6 +251,13 @@ func (q QuoteModel) GetAllForUser(userID int64, content string, filters Filters)
           &quote.UserID,
           &quote.Username,
           &quote.Content,
+          &quote.Likes,
+          &quote.Status,
+          &quote.Visibility,
+          &quote.ModerationReason,
+          &quote.Author,
+          &quote.Source,
+          pq.Array(&quote.Tags),
           &quote.CreatedAt,
        )
        if err != nil {
@@ -178,24 +275,31 @@ func (q QuoteModel) GetAllForUser(userID int64, content string, filters Filters)
     return quotes, metadata, nil
 }
 
-// Get all quotes (with optional content search + pagination)
-func (q QuoteModel) GetAll(content string, filters Filters) ([]*Quote, Metadata, error) {
+// Get all quotes (with optional content search + pagination) that the
+// viewer can see. Anonymous viewers have ID 0 and only see public quotes
+func (q QuoteModel) GetAll(viewerID int64, search QuoteSearch, filters Filters) ([]*Quote, Metadata, error) {
 	query := `
-		SELECT COUNT(*) OVER(), q.quote_id, q.user_id, u.username, q.content, q.created_at
+		SELECT COUNT(*) OVER(), q.quote_id AS id, q.user_id, u.username, q.content, q.likes_count AS popular,
+		       q.status, q.visibility, q.moderation_reason, q.author, q.source, ` + quoteTagsColumn + `, q.created_at
 		FROM quotes q
 		JOIN users u ON q.user_id = u.id
-		WHERE (to_tsvector('simple', q.content) @@ plainto_tsquery('simple', $1) OR $1 = '')
-		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, quote_id ASC
-		LIMIT $2 OFFSET $3`
+		WHERE ` + fuzzyMatch("q.content", "$1", fuzzyTextThreshold) + `
+		AND ` + visibleTo("$2", "false") + `
+		AND ` + hasTags("$3") + `
+		AND (lower(q.author) = lower($4) OR $4 = '')
+		AND (u.username = $5 OR $5 = '')
+		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, id ASC
+		LIMIT $6 OFFSET $7`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := q.DB.QueryContext(ctx, query, content, filters.limit(), filters.offset())
+	args := []any{search.Content, viewerID, pq.Array(search.Tags), search.Author, search.SubmittedBy, filters.limit(), filters.offset()}
+	rows, done, err := fuzzyQuery(ctx, q.DB, query, args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
-	defer rows.Close()
+	defer done()
 
 	totalRecords := 0
 	var quotes []*Quote
@@ -208,6 +312,13 @@ func (q QuoteModel) GetAll(content string, filters Filters) ([]*Quote, Metadata,
 			&quote.UserID,
 			&quote.Username,
 			&quote.Content,
+			&quote.Likes,
+			&quote.Status,
+			&quote.Visibility,
+			&quote.ModerationReason,
+			&quote.Author,
+			&quote.Source,
+			pq.Array(&quote.Tags),
 			&quote.CreatedAt,
 		)
 		if err != nil {
@@ -225,18 +336,48 @@ func (q QuoteModel) GetAll(content string, filters Filters) ([]*Quote, Metadata,
 	return quotes, metadata, nil
 }
 
-func (q QuoteModel) GetByID(id int64) (*Quote, error) {
+// GetByID gets a quote along with the username of whoever submitted it.
+// It returns ErrRecordNotFound when the viewer can't see the quote, and
+// moderator lets them see quotes that aren't approved unless they're private
+func (q QuoteModel) GetByID(id int64, viewerID int64, moderator bool) (*Quote, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
 	query := `
-        SELECT q.quote_id, q.content, q.created_at, u.username 
-		FROM quotes q 
-		JOIN users u 
-		ON q.user_id = u.id 
+		SELECT q.quote_id, q.user_id, u.username, q.content, q.likes_count,
+		       q.status, q.visibility, q.moderation_reason, q.author, q.source, ` + quoteTagsColumn + `, q.created_at
+		FROM quotes q
+		JOIN users u ON q.user_id = u.id
 		WHERE q.quote_id = $1
-    `
+		AND ` + visibleTo("$2", "$3::boolean")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
 	var quote Quote
-	err := q.DB.QueryRow(query, id).Scan(&quote.ID, &quote.UserID, &quote.Username, &quote.Content, &quote.CreatedAt)
+	err := q.DB.QueryRowContext(ctx, query, id, viewerID, moderator).Scan(
+		&quote.ID,
+		&quote.UserID,
+		&quote.Username,
+		&quote.Content,
+		&quote.Likes,
+		&quote.Status,
+		&quote.Visibility,
+		&quote.ModerationReason,
+		&quote.Author,
+		&quote.Source,
+		pq.Array(&quote.Tags),
+		&quote.CreatedAt,
+	)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
 	}
+
 	return &quote, nil
 }
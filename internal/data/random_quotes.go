@@ -0,0 +1,124 @@
+// Filename: internal/data/random_quotes.go
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+	"github.com/lib/pq"
+)
+
+// The most quotes one random request can ask for
+const MaxRandomQuotes = 20
+
+// ValidateRandomCount checks how many random quotes were asked for
+func ValidateRandomCount(v *validator.Validator, count int) {
+	v.Check(count >= 1, "count", "min_value", 1)
+	v.Check(count <= MaxRandomQuotes, "count", "max_value", MaxRandomQuotes)
+}
+
+// Random picks up to count random approved quotes the user can see,
+// leaving out those they hid. Only the tags and author of search are used
+// to narrow them down. Without either, instead of sorting the whole table
+// by random(), it probes a few random points in the ID range and takes the
+// first match at or after each one, wrapping around to the first match
+// overall when there is none, using the primary key index. The cost then
+// depends on count and not on the number of quotes. A quote is as likely to
+// be picked as the run of IDs before it is long, including the quotes the
+// user can't see or hid, and fewer than count come back when probes land on
+// the same quote. A tag or author would make those runs long and uneven, so
+// with one the matching quotes are shuffled instead, at a cost that grows
+// with how many match
+func (q QuoteModel) Random(userID int64, count int, search QuoteSearch) ([]*Quote, error) {
+	match := `
+				q.status = 'approved'
+				AND ` + visibleTo("$1", "false") + `
+				AND ` + notHiddenFrom("$1") + `
+				AND ` + hasTags("$3") + `
+				AND (lower(q.author) = lower($4) OR $4 = '')`
+
+	query := `
+		WITH bounds AS (
+			SELECT MIN(quote_id) AS lo, MAX(quote_id) AS hi
+			FROM quotes
+		), probes AS (
+			SELECT lo + floor(random() * (hi - lo + 1))::bigint AS start
+			FROM bounds, generate_series(1, $2 * 3)
+			WHERE lo IS NOT NULL
+		)
+		SELECT quote_id, user_id, username, content, likes_count, status, visibility, moderation_reason, author, source, tags, created_at
+		FROM (
+			SELECT DISTINCT q.quote_id, q.user_id, COALESCE(u.username, '') AS username, q.content, q.likes_count,
+			       q.status, q.visibility, q.moderation_reason, q.author, q.source, ` + quoteTagsColumn + `, q.created_at
+			FROM probes p
+			CROSS JOIN LATERAL (
+				(SELECT q.*
+				FROM quotes q
+				WHERE q.quote_id >= p.start
+				AND ` + match + `
+				ORDER BY q.quote_id ASC
+				LIMIT 1)
+				UNION ALL
+				(SELECT q.*
+				FROM quotes q
+				WHERE q.quote_id < p.start
+				AND ` + match + `
+				ORDER BY q.quote_id ASC
+				LIMIT 1)
+				LIMIT 1
+			) q
+			LEFT JOIN users u ON q.user_id = u.id
+		) picked
+		ORDER BY random()
+		LIMIT $2`
+
+	if len(search.Tags) > 0 || search.Author != "" {
+		query = `
+		SELECT q.quote_id, q.user_id, COALESCE(u.username, ''), q.content, q.likes_count,
+		       q.status, q.visibility, q.moderation_reason, q.author, q.source, ` + quoteTagsColumn + `, q.created_at
+		FROM quotes q
+		LEFT JOIN users u ON q.user_id = u.id
+		WHERE ` + match + `
+		ORDER BY random()
+		LIMIT $2`
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := q.DB.QueryContext(ctx, query, userID, count, pq.Array(search.Tags), search.Author)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quotes := []*Quote{}
+	for rows.Next() {
+		var quote Quote
+		err := rows.Scan(
+			&quote.ID,
+			&quote.UserID,
+			&quote.Username,
+			&quote.Content,
+			&quote.Likes,
+			&quote.Status,
+			&quote.Visibility,
+			&quote.ModerationReason,
+			&quote.Author,
+			&quote.Source,
+			pq.Array(&quote.Tags),
+			&quote.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, &quote)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return quotes, nil
+}
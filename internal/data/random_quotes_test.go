@@ -0,0 +1,56 @@
+package data
+
+import "testing"
+
+func TestRandom_Filtered(t *testing.T) {
+	db := newTestDB(t)
+	quotes := QuoteModel{DB: db}
+
+	alice := insertTestUser(t, db, "alice")
+	bob := insertTestUser(t, db, "bob")
+	for range 20 {
+		insertTestQuote(t, db, alice.ID, "Untagged", QuoteApproved, VisibilityPublic)
+	}
+	tagged := insertTestQuote(t, db, alice.ID, "Tagged", QuoteApproved, VisibilityPublic, "focus")
+	hidden := insertTestQuote(t, db, alice.ID, "Tagged and hidden", QuoteApproved, VisibilityPublic, "focus")
+	insertTestQuote(t, db, alice.ID, "Tagged and private", QuoteApproved, VisibilityPrivate, "focus")
+	if err := quotes.Hide(bob.ID, hidden.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := quotes.Random(bob.ID, MaxRandomQuotes, QuoteSearch{Tags: []string{"focus"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != tagged.ID {
+		t.Fatalf("got %d quotes; want only %q", len(got), tagged.Content)
+	}
+}
+
+func TestRandom_Unfiltered(t *testing.T) {
+	db := newTestDB(t)
+	quotes := QuoteModel{DB: db}
+
+	alice := insertTestUser(t, db, "alice")
+	bob := insertTestUser(t, db, "bob")
+	visible := map[int64]bool{}
+	for range 10 {
+		visible[insertTestQuote(t, db, alice.ID, "Public", QuoteApproved, VisibilityPublic).ID] = true
+		insertTestQuote(t, db, alice.ID, "Private", QuoteApproved, VisibilityPrivate)
+	}
+
+	got, err := quotes.Random(bob.ID, 5, QuoteSearch{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || len(got) > 5 {
+		t.Fatalf("got %d quotes; want 1 to 5", len(got))
+	}
+	seen := map[int64]bool{}
+	for _, quote := range got {
+		if !visible[quote.ID] || seen[quote.ID] {
+			t.Errorf("got quote %d, which bob can't see or was already picked", quote.ID)
+		}
+		seen[quote.ID] = true
+	}
+}
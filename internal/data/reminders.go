@@ -0,0 +1,266 @@
+// Filename: internal/data/reminders.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Kinds of goal reminder
+const (
+	ReminderDueSoon = "due_soon"
+	ReminderOverdue = "overdue"
+)
+
+// GoalReminder is a reminder that is due to be emailed to a goal's owner
+type GoalReminder struct {
+	GoalID     int64
+	GoalText   string
+	TargetDate string // in DateLayout
+	DaysLeft   int    // negative once the goal is overdue
+	Kind       string
+	UserID     int64
+	Username   string
+	Email      string
+	Locale     string
+}
+
+type ReminderModel struct {
+	DB *sql.DB
+}
+
+// DueGoalReminders finds the open goals whose target date is within
+// windowDays of today, or already past, in the owner's time zone and that
+// haven't been reminded about for that date yet. Owners who are not
+// activated or turned reminders off on every channel are skipped
+func (m ReminderModel) DueGoalReminders(windowDays int, limit int) ([]*GoalReminder, error) {
+	query := `
+		SELECT g.goal_id, g.goal_text, g.target_date::text, g.target_date - t.today, k.kind,
+		       u.id, u.username, u.email, u.locale
+		FROM goals g
+		INNER JOIN users u ON u.id = g.user_id
+		CROSS JOIN LATERAL (SELECT (NOW() AT TIME ZONE u.time_zone)::date AS today) t
+		CROSS JOIN LATERAL (
+			SELECT CASE WHEN g.target_date < t.today THEN 'overdue' ELSE 'due_soon' END AS kind
+		) k
+		WHERE g.is_completed IS NOT TRUE
+		AND g.goal_type = 'one_time'
+		AND g.target_date IS NOT NULL
+		AND g.target_date <= t.today + $1::int
+		AND u.activated
+		AND ` + anyChannelEnabled(CategoryReminders) + `
+		AND NOT EXISTS (
+			SELECT 1 FROM goal_reminders r
+			WHERE r.goal_id = g.goal_id AND r.kind = k.kind AND r.target_date = g.target_date
+		)
+		ORDER BY g.target_date ASC, g.goal_id ASC
+		LIMIT $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, windowDays, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []*GoalReminder
+	for rows.Next() {
+		var reminder GoalReminder
+		err := rows.Scan(
+			&reminder.GoalID,
+			&reminder.GoalText,
+			&reminder.TargetDate,
+			&reminder.DaysLeft,
+			&reminder.Kind,
+			&reminder.UserID,
+			&reminder.Username,
+			&reminder.Email,
+			&reminder.Locale,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, &reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// ClaimGoalReminder records a reminder as sent before it goes out. It
+// returns false if it was already recorded, so that two instances of the
+// API never send the same reminder
+func (m ReminderModel) ClaimGoalReminder(reminder *GoalReminder) (bool, error) {
+	query := `
+		INSERT INTO goal_reminders (goal_id, kind, target_date)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, reminder.GoalID, reminder.Kind, reminder.TargetDate)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// ReleaseGoalReminder removes a claim whose reminder went out on no channel
+// so it is tried again on the next run
+func (m ReminderModel) ReleaseGoalReminder(reminder *GoalReminder) error {
+	query := `
+		DELETE FROM goal_reminders
+		WHERE goal_id = $1 AND kind = $2 AND target_date = $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, reminder.GoalID, reminder.Kind, reminder.TargetDate)
+	return err
+}
+
+// SessionReminder is a reminder that is due for an upcoming study session.
+// Offsets holds every offset that has come due, so a session that has more
+// than one due at once (say after downtime) gets a single email
+type SessionReminder struct {
+	SessionID int64
+	Title     string
+	Subject   string
+	StartTime time.Time
+	Offsets   []int64
+	UserID    int64
+	Username  string
+	Email     string
+	TimeZone  string
+	Locale    string
+}
+
+// DueSessionReminders finds the sessions that haven't started yet and have
+// at least one reminder offset that has come due without being sent. Due
+// reminders that were missed while the server was down are still found, as
+// long as the session hasn't started
+func (m ReminderModel) DueSessionReminders(limit int) ([]*SessionReminder, error) {
+	query := `
+		SELECT s.session_id, s.title, COALESCE(s.subject, ''), s.start_time,
+		       array_agg(o.offset_minutes ORDER BY o.offset_minutes),
+		       u.id, u.username, u.email, u.time_zone, u.locale
+		FROM study_sessions s
+		INNER JOIN users u ON u.id = s.user_id
+		CROSS JOIN LATERAL unnest(s.reminder_offsets) AS o(offset_minutes)
+		WHERE s.reminder_offsets <> '{}'
+		AND s.is_completed IS NOT TRUE
+		AND s.start_time > NOW()
+		AND s.start_time - make_interval(mins => o.offset_minutes) <= NOW()
+		AND u.activated
+		AND ` + anyChannelEnabled(CategoryReminders) + `
+		AND NOT EXISTS (
+			SELECT 1 FROM session_reminders r
+			WHERE r.session_id = s.session_id
+			AND r.offset_minutes = o.offset_minutes
+			AND r.start_time = s.start_time
+		)
+		GROUP BY s.session_id, u.id
+		ORDER BY s.start_time ASC
+		LIMIT $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []*SessionReminder
+	for rows.Next() {
+		var reminder SessionReminder
+		err := rows.Scan(
+			&reminder.SessionID,
+			&reminder.Title,
+			&reminder.Subject,
+			&reminder.StartTime,
+			pq.Array(&reminder.Offsets),
+			&reminder.UserID,
+			&reminder.Username,
+			&reminder.Email,
+			&reminder.TimeZone,
+			&reminder.Locale,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, &reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// ClaimSessionReminder records the due offsets of a session reminder as
+// sent. Offsets already claimed by another run are dropped from the
+// reminder, and it returns false if none were left
+func (m ReminderModel) ClaimSessionReminder(reminder *SessionReminder) (bool, error) {
+	query := `
+		INSERT INTO session_reminders (session_id, offset_minutes, start_time)
+		SELECT $1, o, $2 FROM unnest($3::int[]) AS o
+		ON CONFLICT DO NOTHING
+		RETURNING offset_minutes`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, reminder.SessionID, reminder.StartTime, pq.Array(reminder.Offsets))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	claimed := []int64{}
+	for rows.Next() {
+		var offset int64
+		if err := rows.Scan(&offset); err != nil {
+			return false, err
+		}
+		claimed = append(claimed, offset)
+	}
+
+	if err = rows.Err(); err != nil {
+		return false, err
+	}
+
+	reminder.Offsets = claimed
+	return len(claimed) > 0, nil
+}
+
+// ReleaseSessionReminder removes the claim on a session reminder that could
+// not be delivered so the next run tries again
+func (m ReminderModel) ReleaseSessionReminder(reminder *SessionReminder) error {
+	query := `
+		DELETE FROM session_reminders
+		WHERE session_id = $1 AND start_time = $2 AND offset_minutes = ANY($3::int[])`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, reminder.SessionID, reminder.StartTime, pq.Array(reminder.Offsets))
+	return err
+}
@@ -0,0 +1,138 @@
+// Filename: internal/data/search.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// What a search can look through
+const (
+	SearchSessions = "sessions"
+	SearchGoals    = "goals"
+	SearchQuotes   = "quotes"
+)
+
+var SearchTypes = []string{SearchSessions, SearchGoals, SearchQuotes}
+
+// The most results a search returns of each type
+const MaxSearchResults = 50
+
+// headlineOptions wraps each match in a snippet in <mark> tags
+const headlineOptions = `'StartSel=<mark>, StopSel=</mark>, MinWords=10, MaxWords=30, MaxFragments=2, FragmentDelimiter=" … "'`
+
+// htmlEscaped escapes the text of a SQL expression for use in HTML. It is
+// applied before ts_headline, whose parser keeps the entities whole, so
+// the <mark> tags are the only markup in a snippet
+func htmlEscaped(expr string) string {
+	return `replace(replace(replace(replace(` + expr + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`
+}
+
+// SearchResult is a session, goal or quote that matched. Title is the
+// session's title, the goal's text or the quote's author, as plain text.
+// Snippet is HTML that highlights the matching words
+type SearchResult struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchGroup is the best results of one type, and how many matched in all
+type SearchGroup struct {
+	Total   int             `json:"total"`
+	Results []*SearchResult `json:"results"`
+}
+
+// ValidateSearch checks the search text, the types to search and how many
+// results to return of each
+func ValidateSearch(v *validator.Validator, query string, types []string, limit int) {
+	v.Check(query != "", "q", "required")
+	v.Check(len(query) <= 200, "q", "max_bytes", 200)
+	for _, t := range types {
+		if !validator.PermittedValue(t, SearchTypes...) {
+			v.AddError("type", "one_of", "sessions, goals, quotes")
+			break
+		}
+	}
+	v.Check(limit >= 1, "limit", "min_value", 1)
+	v.Check(limit <= MaxSearchResults, "limit", "max_value", MaxSearchResults)
+}
+
+type SearchModel struct {
+	DB *sql.DB
+}
+
+// Search looks for query in the user's sessions or goals, or in the quotes
+// they can see, depending on kind, and returns the limit best ranked.
+// Sessions and goals are only the user's own, stemmed for their locale, so
+// the query is stemmed for it too. Quotes come from users of any locale and
+// are indexed without stemming, so the query for them isn't stemmed either
+func (m SearchModel) Search(kind string, userID int64, locale string, query string, limit int) (*SearchGroup, error) {
+	config := "search_config($4)"
+	args := []any{userID, query, limit, locale}
+
+	var statement string
+	switch kind {
+	case SearchSessions:
+		statement = `
+			SELECT COUNT(*) OVER(), s.session_id, s.title,
+			       ts_headline(c.config, ` + htmlEscaped("concat_ws(' ', s.title, s.subject, s.description)") + `, c.query, ` + headlineOptions + `),
+			       ts_rank(s.search_vector, c.query) AS rank, s.created_at
+			FROM study_sessions s, search c
+			WHERE s.user_id = $1 AND s.search_vector @@ c.query`
+	case SearchGoals:
+		statement = `
+			SELECT COUNT(*) OVER(), g.goal_id, g.goal_text,
+			       ts_headline(c.config, ` + htmlEscaped("concat_ws(' ', g.goal_text, g.subject)") + `, c.query, ` + headlineOptions + `),
+			       ts_rank(g.search_vector, c.query) AS rank, g.created_at
+			FROM goals g, search c
+			WHERE g.user_id = $1 AND g.search_vector @@ c.query`
+	case SearchQuotes:
+		config = "'simple'::regconfig"
+		args = args[:3]
+		statement = `
+			SELECT COUNT(*) OVER(), q.quote_id, q.author,
+			       ts_headline(c.config, ` + htmlEscaped("q.content") + `, c.query, ` + headlineOptions + `),
+			       ts_rank(q.search_vector, c.query) AS rank, q.created_at
+			FROM quotes q, search c
+			WHERE q.search_vector @@ c.query
+			AND ` + visibleTo("$1", "false")
+	}
+
+	statement = `
+		WITH search AS (
+			SELECT ` + config + ` AS config, websearch_to_tsquery(` + config + `, $2) AS query
+		)` + statement + `
+		ORDER BY rank DESC, created_at DESC
+		LIMIT $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	group := &SearchGroup{Results: []*SearchResult{}}
+	for rows.Next() {
+		var result SearchResult
+		err := rows.Scan(&group.Total, &result.ID, &result.Title, &result.Snippet, &result.Rank, &result.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		group.Results = append(group.Results, &result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
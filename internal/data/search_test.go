@@ -0,0 +1,74 @@
+package data
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearch_SnippetIsEscaped(t *testing.T) {
+	db := newTestDB(t)
+	search := SearchModel{DB: db}
+
+	alice := insertTestUser(t, db, "alice")
+	insertTestQuote(t, db, alice.ID, `<b>focus</b> & "focus"`, QuoteApproved, VisibilityPublic)
+
+	group, err := search.Search(SearchQuotes, alice.ID, "en", "focus", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(group.Results) != 1 {
+		t.Fatalf("got %d results; want 1", len(group.Results))
+	}
+
+	snippet := group.Results[0].Snippet
+	if strings.Contains(snippet, "<b>") {
+		t.Errorf("snippet = %s; want the quote's HTML escaped", snippet)
+	}
+	for _, want := range []string{"&lt;b&gt;", "&amp;", "&quot;", "<mark>focus</mark>"} {
+		if !strings.Contains(snippet, want) {
+			t.Errorf("snippet = %s; want it to contain %s", snippet, want)
+		}
+	}
+}
+
+func TestFuzzyMatch_Threshold(t *testing.T) {
+	filter := fuzzyMatch("q.content", "$3", fuzzyNameThreshold)
+
+	if !strings.Contains(filter, "word_similarity($3, q.content) >= 0.5") {
+		t.Errorf("fuzzyMatch() = %s; want the 0.5 threshold checked", filter)
+	}
+	if !strings.Contains(filter, likeEscaped("$3")) {
+		t.Errorf("fuzzyMatch() = %s; want the search escaped for ILIKE", filter)
+	}
+}
+
+func TestFuzzyQuery_FindsCloseWords(t *testing.T) {
+	db := newTestDB(t)
+	sessions := StudySessionModel{DB: db}
+
+	// "calcls" shares 4 of its 7 trigrams with "calculus", too few for the
+	// default threshold of 0.6 but enough for the API's 0.4
+	alice := insertTestUser(t, db, "alice")
+	start := time.Now()
+	err := sessions.Insert(&StudySession{
+		UserID:          alice.ID,
+		Title:           "Revision",
+		Subject:         "Calculus",
+		StartTime:       start,
+		EndTime:         start.Add(time.Hour),
+		Tags:            []string{},
+		ReminderOffsets: []int64{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suggestions, err := sessions.Suggest(alice.ID, SuggestSubject, "calcls", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Value != "Calculus" {
+		t.Errorf("got %+v; want Calculus", suggestions)
+	}
+}
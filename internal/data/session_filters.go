@@ -0,0 +1,61 @@
+// Filename: internal/data/session_filters.go
+package data
+
+import (
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// Where a study session is relative to now
+const (
+	SessionUpcoming   = "upcoming"
+	SessionInProgress = "in_progress"
+	SessionPast       = "past"
+)
+
+var SessionStatuses = []string{SessionUpcoming, SessionInProgress, SessionPast}
+
+// The longest duration a session search can ask for, a week in minutes
+const maxSearchMinutes = 7 * 24 * 60
+
+// SessionSearch narrows down a list of study sessions. A session needs one
+// of Subjects, and From and To are the first and last days it can start on
+// in TimeZone. Durations are in minutes, and zero or empty fields match
+// everything
+type SessionSearch struct {
+	Title       string
+	Subjects    []string
+	IsCompleted *bool
+	From        time.Time
+	To          time.Time
+	TimeZone    string
+	MinMinutes  int
+	MaxMinutes  int
+	Status      string
+}
+
+// ValidateSessionSearch checks the filters of a session list
+func ValidateSessionSearch(v *validator.Validator, search SessionSearch) {
+	v.Check(len(search.Subjects) <= 10, "subject", "max_items", 10)
+	for _, subject := range search.Subjects {
+		v.Check(len(subject) <= 100, "subject", "max_item_bytes", 100)
+	}
+	if !search.From.IsZero() && !search.To.IsZero() {
+		v.Check(!search.From.After(search.To), "from", "after_end_date")
+	}
+	v.Check(search.MinMinutes >= 0, "min_minutes", "not_negative")
+	v.Check(search.MaxMinutes >= 0, "max_minutes", "not_negative")
+	v.Check(search.MinMinutes <= maxSearchMinutes, "min_minutes", "max_value", maxSearchMinutes)
+	v.Check(search.MaxMinutes <= maxSearchMinutes, "max_minutes", "max_value", maxSearchMinutes)
+	v.Check(search.MaxMinutes == 0 || search.MaxMinutes >= search.MinMinutes, "max_minutes", "below_min_minutes")
+	v.Check(search.Status == "" || validator.PermittedValue(search.Status, SessionStatuses...), "status", "one_of", "upcoming, in_progress, past")
+}
+
+// dateParam is the date for a query parameter, or NULL when it isn't set
+func dateParam(date time.Time) any {
+	if date.IsZero() {
+		return nil
+	}
+	return date.Format(DateLayout)
+}
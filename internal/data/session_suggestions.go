@@ -0,0 +1,82 @@
+// Filename: internal/data/session_suggestions.go
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// The study session fields that can be autocompleted
+const (
+	SuggestSubject = "subject"
+	SuggestTitle   = "title"
+)
+
+// The most suggestions one request can ask for
+const MaxSuggestions = 20
+
+// Suggestion is a subject or title the user has used, and how many of their
+// sessions have it
+type Suggestion struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// ValidateSuggestions checks an autocomplete request
+func ValidateSuggestions(v *validator.Validator, field string, prefix string, limit int) {
+	v.Check(validator.PermittedValue(field, SuggestSubject, SuggestTitle), "field", "one_of", "subject, title")
+	v.Check(len(prefix) <= 100, "q", "max_bytes", 100)
+	v.Check(limit >= 1, "limit", "min_value", 1)
+	v.Check(limit <= MaxSuggestions, "limit", "max_value", MaxSuggestions)
+}
+
+// Suggest completes what the user has typed from the subjects or titles of
+// their own sessions. Values that start with it come first, then the
+// closest fuzzy matches, then the most used. An empty prefix lists the most
+// used
+func (m StudySessionModel) Suggest(userID int64, field string, prefix string, limit int) ([]*Suggestion, error) {
+	column := "subject"
+	if field == SuggestTitle {
+		column = "title"
+	}
+
+	query := `
+		SELECT value, COUNT(*) AS count
+		FROM (
+			SELECT ` + column + ` AS value
+			FROM study_sessions
+			WHERE user_id = $1 AND COALESCE(` + column + `, '') <> ''
+		) s
+		WHERE ` + fuzzyMatch("value", "$2", fuzzyTextThreshold) + `
+		GROUP BY value
+		ORDER BY starts_with(lower(value), lower($2)) DESC, word_similarity($2, value) DESC,
+		         count DESC, value ASC
+		LIMIT $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, done, err := fuzzyQuery(ctx, m.DB, query, userID, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	suggestions := []*Suggestion{}
+	for rows.Next() {
+		var suggestion Suggestion
+		err := rows.Scan(&suggestion.Value, &suggestion.Count)
+		if err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, &suggestion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
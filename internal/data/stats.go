@@ -0,0 +1,414 @@
+// Filename: internal/data/stats.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/aiycoleman/Study-Mate/internal/validator"
+)
+
+// The layout used for the calendar dates in the stats endpoints
+const DateLayout = "2006-01-02"
+
+// The longest date range a single stats request may cover
+const MaxStatsRangeDays = 366
+
+// Totals for all the sessions in the requested range
+type StudyTotals struct {
+	FocusedMinutes    int `json:"focused_minutes"`
+	TotalSessions     int `json:"total_sessions"`
+	CompletedSessions int `json:"completed_sessions"`
+	PlannedSessions   int `json:"planned_sessions"`
+}
+
+// Totals for one subject
+type SubjectStats struct {
+	Subject           string `json:"subject"`
+	FocusedMinutes    int    `json:"focused_minutes"`
+	CompletedSessions int    `json:"completed_sessions"`
+	PlannedSessions   int    `json:"planned_sessions"`
+}
+
+// One bucket of a daily, weekly or monthly series. PeriodStart is the
+// first day of the bucket in the user's time zone
+type SeriesPoint struct {
+	PeriodStart       string `json:"period_start"`
+	FocusedMinutes    int    `json:"focused_minutes"`
+	CompletedSessions int    `json:"completed_sessions"`
+	PlannedSessions   int    `json:"planned_sessions"`
+}
+
+// Completion rates for the goals due in the requested range
+type GoalStats struct {
+	TotalGoals     int     `json:"total_goals"`
+	CompletedGoals int     `json:"completed_goals"`
+	OverdueGoals   int     `json:"overdue_goals"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+type StudyStats struct {
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	TimeZone       string          `json:"time_zone"`
+	FirstDayOfWeek int             `json:"first_day_of_week"`
+	Totals         StudyTotals     `json:"totals"`
+	Subjects       []*SubjectStats `json:"subjects"`
+	Daily          []*SeriesPoint  `json:"daily"`
+	Weekly         []*SeriesPoint  `json:"weekly"`
+	Monthly        []*SeriesPoint  `json:"monthly"`
+	Goals          GoalStats       `json:"goals"`
+}
+
+// Validation checks for a stats date range
+func ValidateStatsRange(v *validator.Validator, from time.Time, to time.Time) {
+	v.Check(!from.IsZero(), "from", "invalid_date")
+	v.Check(!to.IsZero(), "to", "invalid_date")
+	if from.IsZero() || to.IsZero() {
+		return
+	}
+	v.Check(!from.After(to), "from", "after_end_date")
+	v.Check(to.Sub(from) < MaxStatsRangeDays*24*time.Hour, "to", "range_too_long", MaxStatsRangeDays)
+}
+
+type StatsModel struct {
+	DB *sql.DB
+}
+
+// StudyForUser computes the study analytics for a user between the two
+// calendar dates (inclusive), bucketing the sessions in the given time zone.
+// Weekly buckets start on firstDayOfWeek (0 is Sunday)
+func (m StatsModel) StudyForUser(userID int64, from time.Time, to time.Time, tz string, firstDayOfWeek int) (*StudyStats, error) {
+	stats := &StudyStats{
+		From:           from.Format(DateLayout),
+		To:             to.Format(DateLayout),
+		TimeZone:       tz,
+		FirstDayOfWeek: firstDayOfWeek,
+	}
+
+	err := m.studyTotals(userID, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Subjects, err = m.subjectBreakdown(userID, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Daily, err = m.series(userID, stats, "day")
+	if err != nil {
+		return nil, err
+	}
+	stats.Weekly, err = m.series(userID, stats, "week")
+	if err != nil {
+		return nil, err
+	}
+	stats.Monthly, err = m.series(userID, stats, "month")
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.goalCompletion(userID, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// The sessions that start inside the range. The range is given as two dates
+// and converted to timestamps in the user's time zone
+const statsSessionsInRange = `
+		SELECT subject, is_completed,
+		       start_time AT TIME ZONE $4 AS local_start,
+		       EXTRACT(EPOCH FROM (end_time - start_time)) / 60 AS minutes
+		FROM study_sessions
+		WHERE user_id = $1
+		AND start_time >= ($2::date)::timestamp AT TIME ZONE $4
+		AND start_time < ($3::date + 1)::timestamp AT TIME ZONE $4`
+
+func (m StatsModel) studyTotals(userID int64, stats *StudyStats) error {
+	query := `
+		WITH s AS (` + statsSessionsInRange + `)
+		SELECT COALESCE(ROUND(SUM(minutes) FILTER (WHERE is_completed)), 0)::int,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE is_completed),
+		       COUNT(*) FILTER (WHERE is_completed IS NOT TRUE)
+		FROM s`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, userID, stats.From, stats.To, stats.TimeZone).Scan(
+		&stats.Totals.FocusedMinutes,
+		&stats.Totals.TotalSessions,
+		&stats.Totals.CompletedSessions,
+		&stats.Totals.PlannedSessions,
+	)
+}
+
+func (m StatsModel) subjectBreakdown(userID int64, stats *StudyStats) ([]*SubjectStats, error) {
+	query := `
+		WITH s AS (` + statsSessionsInRange + `)
+		SELECT COALESCE(NULLIF(subject, ''), 'Unspecified') AS subject_name,
+		       COALESCE(ROUND(SUM(minutes) FILTER (WHERE is_completed)), 0)::int,
+		       COUNT(*) FILTER (WHERE is_completed),
+		       COUNT(*) FILTER (WHERE is_completed IS NOT TRUE)
+		FROM s
+		GROUP BY subject_name
+		ORDER BY 2 DESC, subject_name ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, stats.From, stats.To, stats.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subjects := []*SubjectStats{}
+	for rows.Next() {
+		var s SubjectStats
+		err := rows.Scan(&s.Subject, &s.FocusedMinutes, &s.CompletedSessions, &s.PlannedSessions)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subjects, nil
+}
+
+// series buckets the sessions by day, week or month. Empty buckets are
+// included so the client can draw a continuous chart
+func (m StatsModel) series(userID int64, stats *StudyStats, unit string) ([]*SeriesPoint, error) {
+	// date_trunc starts weeks on Monday, so shift by the number of days
+	// between Monday and the user's first day of the week
+	shift := 0
+	if unit == "week" {
+		shift = (stats.FirstDayOfWeek + 6) % 7
+	}
+
+	query := `
+		WITH s AS (` + statsSessionsInRange + `),
+		buckets AS (
+			SELECT generate_series(
+				date_trunc($5, ($2::date - $6::int)::timestamp) + make_interval(days => $6),
+				date_trunc($5, ($3::date - $6::int)::timestamp) + make_interval(days => $6),
+				('1 ' || $5)::interval
+			) AS bucket
+		)
+		SELECT to_char(b.bucket, 'YYYY-MM-DD'),
+		       COALESCE(ROUND(SUM(s.minutes) FILTER (WHERE s.is_completed)), 0)::int,
+		       COUNT(s.local_start) FILTER (WHERE s.is_completed),
+		       COUNT(s.local_start) FILTER (WHERE s.is_completed IS NOT TRUE)
+		FROM buckets b
+		LEFT JOIN s ON date_trunc($5, s.local_start - make_interval(days => $6)) + make_interval(days => $6) = b.bucket
+		GROUP BY b.bucket
+		ORDER BY b.bucket ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, stats.From, stats.To, stats.TimeZone, unit, shift)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []*SeriesPoint{}
+	for rows.Next() {
+		var p SeriesPoint
+		err := rows.Scan(&p.PeriodStart, &p.FocusedMinutes, &p.CompletedSessions, &p.PlannedSessions)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, &p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// goalCompletion reports on the goals whose target date falls in the range.
+// A goal is overdue when it is not completed and its date has passed in the
+// user's time zone
+func (m StatsModel) goalCompletion(userID int64, stats *StudyStats) error {
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE is_completed),
+		       COUNT(*) FILTER (WHERE is_completed IS NOT TRUE AND target_date < (NOW() AT TIME ZONE $4)::date)
+		FROM goals
+		WHERE user_id = $1
+		AND target_date BETWEEN $2::date AND $3::date`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, userID, stats.From, stats.To, stats.TimeZone).Scan(
+		&stats.Goals.TotalGoals,
+		&stats.Goals.CompletedGoals,
+		&stats.Goals.OverdueGoals,
+	)
+	if err != nil {
+		return err
+	}
+
+	if stats.Goals.TotalGoals > 0 {
+		stats.Goals.CompletionRate = float64(stats.Goals.CompletedGoals) / float64(stats.Goals.TotalGoals)
+	}
+
+	return nil
+}
+
+// Minutes studied on one calendar day
+type DayActivity struct {
+	Date    string `json:"date"`
+	Minutes int    `json:"minutes"`
+}
+
+// A run of consecutive days that met the daily threshold
+type Streak struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Length int    `json:"length"`
+}
+
+type StreakStats struct {
+	TimeZone        string         `json:"time_zone"`
+	Today           string         `json:"today"`
+	MinDailyMinutes int            `json:"min_daily_minutes"`
+	CurrentStreak   int            `json:"current_streak"`
+	LongestStreak   *Streak        `json:"longest_streak"`
+	Days            []*DayActivity `json:"days"`
+}
+
+// StreaksForUser works out the current and longest streaks of days with at
+// least minMinutes of completed study, plus the minutes for every day of the
+// year ending on today. Days are counted in the given time zone
+func (m StatsModel) StreaksForUser(userID int64, today time.Time, tz string, minMinutes int) (*StreakStats, error) {
+	stats := &StreakStats{
+		TimeZone:        tz,
+		Today:           today.Format(DateLayout),
+		MinDailyMinutes: minMinutes,
+	}
+
+	streaks, err := m.streaks(userID, tz, minMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	// The streak is still alive if it reached yesterday, since today may
+	// not have been studied yet
+	yesterday := today.AddDate(0, 0, -1).Format(DateLayout)
+	for _, s := range streaks {
+		if s.End == stats.Today || s.End == yesterday {
+			stats.CurrentStreak = s.Length
+		}
+		if stats.LongestStreak == nil || s.Length > stats.LongestStreak.Length {
+			stats.LongestStreak = s
+		}
+	}
+
+	from := today.AddDate(-1, 0, 1)
+	stats.Days, err = m.dailyMinutes(userID, from, today, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// streaks finds every run of qualifying days using the gaps-and-islands
+// trick: consecutive days minus their row number share the same value
+func (m StatsModel) streaks(userID int64, tz string, minMinutes int) ([]*Streak, error) {
+	query := `
+		WITH days AS (
+			SELECT (start_time AT TIME ZONE $2)::date AS day
+			FROM study_sessions
+			WHERE user_id = $1 AND is_completed
+			GROUP BY day
+			HAVING SUM(EXTRACT(EPOCH FROM (end_time - start_time)) / 60) >= $3
+		),
+		islands AS (
+			SELECT day, day - (ROW_NUMBER() OVER (ORDER BY day))::int AS grp
+			FROM days
+		)
+		SELECT to_char(MIN(day), 'YYYY-MM-DD'), to_char(MAX(day), 'YYYY-MM-DD'), COUNT(*)
+		FROM islands
+		GROUP BY grp
+		ORDER BY MAX(day) DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, tz, minMinutes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var streaks []*Streak
+	for rows.Next() {
+		var s Streak
+		err := rows.Scan(&s.Start, &s.End, &s.Length)
+		if err != nil {
+			return nil, err
+		}
+		streaks = append(streaks, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return streaks, nil
+}
+
+// dailyMinutes returns the completed minutes for every day in the range,
+// including the days with no study at all
+func (m StatsModel) dailyMinutes(userID int64, from time.Time, to time.Time, tz string) ([]*DayActivity, error) {
+	query := `
+		WITH s AS (` + statsSessionsInRange + `)
+		SELECT to_char(d.day, 'YYYY-MM-DD'),
+		       COALESCE(ROUND(SUM(s.minutes) FILTER (WHERE s.is_completed)), 0)::int
+		FROM generate_series($2::date::timestamp, $3::date::timestamp, INTERVAL '1 day') AS d(day)
+		LEFT JOIN s ON s.local_start::date = d.day::date
+		GROUP BY d.day
+		ORDER BY d.day ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, from.Format(DateLayout), to.Format(DateLayout), tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []*DayActivity{}
+	for rows.Next() {
+		var d DayActivity
+		err := rows.Scan(&d.Date, &d.Minutes)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
@@ -6,36 +6,76 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/aiycoleman/Study-Mate/internal/validator"
+	"github.com/lib/pq"
 )
 
 type StudySession struct {
-	ID          int64     `json:"id"`
-	UserID      int64     `json:"user_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Subject     string    `json:"subject"`
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
-	IsCompleted bool      `json:"is_completed"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID              int64     `json:"id"`
+	UserID          int64     `json:"user_id"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	Subject         string    `json:"subject"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	Tags            []string  `json:"tags"`
+	ReminderOffsets []int64   `json:"reminder_offsets"` // minutes before the start, e.g. 10 or 60
+	IsCompleted     bool      `json:"is_completed"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// NormalizeTags trims, lowercases and de-duplicates the tags so that goals
+// can match them reliably
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// NormalizeReminderOffsets sorts the offsets and drops duplicates
+func NormalizeReminderOffsets(offsets []int64) []int64 {
+	normalized := []int64{}
+	for _, offset := range offsets {
+		if !slices.Contains(normalized, offset) {
+			normalized = append(normalized, offset)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
 }
 
 // Validation checks for StudySession
 func ValidateStudySession(v *validator.Validator, s *StudySession) {
-	v.Check(s.Title != "", "title", "must be provided")
-	v.Check(len(s.Title) <= 100, "title", "must not be more than 100 bytes long")
-	v.Check(s.UserID > 0, "user_id", "must be a valid user ID")
+	v.Check(s.Title != "", "title", "required")
+	v.Check(len(s.Title) <= 100, "title", "max_bytes", 100)
+	v.Check(s.UserID > 0, "user_id", "invalid_user_id")
 
-	v.Check(!s.StartTime.IsZero(), "start_time", "must be provided")
-	v.Check(!s.EndTime.IsZero(), "end_time", "must be provided")
-	v.Check(s.EndTime.After(s.StartTime), "end_time", "must be after the start time")
+	v.Check(!s.StartTime.IsZero(), "start_time", "required")
+	v.Check(!s.EndTime.IsZero(), "end_time", "required")
+	v.Check(s.EndTime.After(s.StartTime), "end_time", "before_start")
 
 	// Optional fields but should not exceed length limits
-	v.Check(len(s.Description) <= 500, "description", "must not be more than 500 bytes long")
-	v.Check(len(s.Subject) <= 100, "subject", "must not be more than 100 bytes long")
+	v.Check(len(s.Description) <= 500, "description", "max_bytes", 500)
+	v.Check(len(s.Subject) <= 100, "subject", "max_bytes", 100)
+
+	v.Check(len(s.Tags) <= 10, "tags", "max_items", 10)
+	for _, tag := range s.Tags {
+		v.Check(len(tag) <= 50, "tags", "max_item_bytes", 50)
+	}
+
+	v.Check(len(s.ReminderOffsets) <= 5, "reminder_offsets", "max_items", 5)
+	for _, offset := range s.ReminderOffsets {
+		v.Check(offset >= 1 && offset <= 7*24*60, "reminder_offsets", "reminder_out_of_range")
+	}
 }
 
 type StudySessionModel struct {
@@ -45,11 +85,11 @@ type StudySessionModel struct {
 // Insert a new study session
 func (m StudySessionModel) Insert(s *StudySession) error {
 	query := `
-		INSERT INTO study_sessions (user_id, title, description, subject, start_time, end_time, is_completed)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO study_sessions (user_id, title, description, subject, start_time, end_time, is_completed, tags, reminder_offsets)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING session_id, created_at`
 
-	args := []any{s.UserID, s.Title, s.Description, s.Subject, s.StartTime, s.EndTime, s.IsCompleted}
+	args := []any{s.UserID, s.Title, s.Description, s.Subject, s.StartTime, s.EndTime, s.IsCompleted, pq.Array(s.Tags), pq.Array(s.ReminderOffsets)}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -64,7 +104,7 @@ func (m StudySessionModel) Get(id int64) (*StudySession, error) {
 	}
 
 	query := `
-		SELECT session_id, user_id, title, description, subject, start_time, end_time, is_completed, created_at
+		SELECT session_id, user_id, title, description, subject, start_time, end_time, tags, reminder_offsets, is_completed, created_at
 		FROM study_sessions
 		WHERE session_id = $1`
 
@@ -81,6 +121,8 @@ func (m StudySessionModel) Get(id int64) (*StudySession, error) {
 		&s.Subject,
 		&s.StartTime,
 		&s.EndTime,
+		pq.Array(&s.Tags),
+		pq.Array(&s.ReminderOffsets),
 		&s.IsCompleted,
 		&s.CreatedAt,
 	)
@@ -101,11 +143,11 @@ func (m StudySessionModel) Get(id int64) (*StudySession, error) {
 func (m StudySessionModel) Update(s *StudySession) error {
 	query := `
 		UPDATE study_sessions
-		SET title = $1, description = $2, subject = $3, start_time = $4, end_time = $5, is_completed = $6
-		WHERE session_id = $7
-		RETURNING session_id, user_id, title, description, subject, start_time, end_time, is_completed, created_at`
+		SET title = $1, description = $2, subject = $3, start_time = $4, end_time = $5, is_completed = $6, tags = $7, reminder_offsets = $8
+		WHERE session_id = $9
+		RETURNING session_id, user_id, title, description, subject, start_time, end_time, tags, reminder_offsets, is_completed, created_at`
 
-	args := []any{s.Title, s.Description, s.Subject, s.StartTime, s.EndTime, s.IsCompleted, s.ID}
+	args := []any{s.Title, s.Description, s.Subject, s.StartTime, s.EndTime, s.IsCompleted, pq.Array(s.Tags), pq.Array(s.ReminderOffsets), s.ID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -118,6 +160,8 @@ func (m StudySessionModel) Update(s *StudySession) error {
 		&s.Subject,
 		&s.StartTime,
 		&s.EndTime,
+		pq.Array(&s.Tags),
+		pq.Array(&s.ReminderOffsets),
 		&s.IsCompleted,
 		&s.CreatedAt,
 	)
@@ -153,26 +197,45 @@ func (m StudySessionModel) Delete(id int64) error {
 	return nil
 }
 
-// GetAllForUser study sessions for a specific user
-func (m StudySessionModel) GetAllForUser(userID int64, title string, subject string, isCompleted *bool, filters Filters) ([]*StudySession, Metadata, error) {
+// GetAllForUser study sessions for a specific user. duration sorts by how
+// long they are
+func (m StudySessionModel) GetAllForUser(userID int64, search SessionSearch, filters Filters) ([]*StudySession, Metadata, error) {
     query := `
-       SELECT COUNT(*) OVER(), session_id, user_id, title, description, subject, start_time, end_time, is_completed, created_at
-       FROM study_sessions
+       SELECT COUNT(*) OVER(), session_id, user_id, title, description, subject, start_time, end_time, tags, reminder_offsets, is_completed, created_at
+       FROM study_sessions, LATERAL (SELECT end_time - start_time AS duration) d
        WHERE user_id = $1
-       AND (to_tsvector('simple', title) @@ plainto_tsquery('simple', $2) OR $2 = '')
-       AND (to_tsvector('simple', subject) @@ plainto_tsquery('simple', $3) OR $3 = '')
+       AND ` + fuzzyMatch("title", "$2", fuzzyTextThreshold) + `
+       AND (cardinality($3::text[]) = 0 OR EXISTS (
+          SELECT 1
+          FROM unnest($3::text[]) AS wanted(subject)
+          WHERE ` + fuzzyMatch("study_sessions.subject", "wanted.subject", fuzzyTextThreshold) + `
+       ))
        AND ($4::boolean IS NULL OR is_completed = $4)
-       ORDER BY  + filters.sortColumn() +   + filters.sortDirection() + , session_id ASC
-       LIMIT $5 OFFSET $6`
+       AND ($5::date IS NULL OR start_time >= ($5::date)::timestamp AT TIME ZONE $7)
+       AND ($6::date IS NULL OR start_time < ($6::date + 1)::timestamp AT TIME ZONE $7)
+       AND ($8 = 0 OR duration >= make_interval(mins => $8))
+       AND ($9 = 0 OR duration <= make_interval(mins => $9))
+       AND ($10 = ''
+          OR ($10 = 'upcoming' AND start_time > NOW())
+          OR ($10 = 'in_progress' AND start_time <= NOW() AND end_time > NOW())
+          OR ($10 = 'past' AND end_time <= NOW()))
+       ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, session_id ASC
+       LIMIT $11 OFFSET $12`
 
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    rows, err := m.DB.QueryContext(ctx, query, userID, title, subject, isCompleted, filters.limit(), filters.offset())
+    args := []any{
+       userID, search.Title, pq.Array(search.Subjects), search.IsCompleted,
+       dateParam(search.From), dateParam(search.To), search.TimeZone,
+       search.MinMinutes, search.MaxMinutes, search.Status,
+       filters.limit(), filters.offset(),
+    }
+    rows, done, err := fuzzyQuery(ctx, m.DB, query, args...)
     if err != nil {
        return nil, Metadata{}, err
     }
-    defer rows.Close()
+    defer done()
 
     totalRecords := 0
     var sessions []*StudySession
@@ -188,6 +251,8 @@ func (m StudySessionModel) GetAllForUser(userID int64, title string, subject str
           &s.Subject,
           &s.StartTime,
           &s.EndTime,
+          pq.Array(&s.Tags),
+          pq.Array(&s.ReminderOffsets),
           &s.IsCompleted,
           &s.CreatedAt,
        )
@@ -208,10 +273,10 @@ func (m StudySessionModel) GetAllForUser(userID int64, title string, subject str
 // GetAll study sessions with optional filters (by subject/title/is_completed)
 func (m StudySessionModel) GetAll(title string, subject string, isCompleted *bool, filters Filters) ([]*StudySession, Metadata, error) {
 	query := `
-		SELECT COUNT(*) OVER(), session_id, user_id, title, description, subject, start_time, end_time, is_completed, created_at
+		SELECT COUNT(*) OVER(), session_id, user_id, title, description, subject, start_time, end_time, tags, reminder_offsets, is_completed, created_at
 		FROM study_sessions
-		WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
-		AND (to_tsvector('simple', subject) @@ plainto_tsquery('simple', $2) OR $2 = '')
+		WHERE ` + fuzzyMatch("title", "$1", fuzzyTextThreshold) + `
+		AND ` + fuzzyMatch("subject", "$2", fuzzyTextThreshold) + `
 		AND ($3::boolean IS NULL OR is_completed = $3)
 		ORDER BY ` + filters.sortColumn() + ` ` + filters.sortDirection() + `, session_id ASC
 		LIMIT $4 OFFSET $5`
@@ -219,11 +284,11 @@ func (m StudySessionModel) GetAll(title string, subject string, isCompleted *boo
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, title, subject, isCompleted, filters.limit(), filters.offset())
+	rows, done, err := fuzzyQuery(ctx, m.DB, query, title, subject, isCompleted, filters.limit(), filters.offset())
 	if err != nil {
 		return nil, Metadata{}, err
 	}
-	defer rows.Close()
+	defer done()
 
 	totalRecords := 0
 	var sessions []*StudySession
@@ -239,6 +304,8 @@ func (m StudySessionModel) GetAll(title string, subject string, isCompleted *boo
 			&s.Subject,
 			&s.StartTime,
 			&s.EndTime,
+			pq.Array(&s.Tags),
+			pq.Array(&s.ReminderOffsets),
 			&s.IsCompleted,
 			&s.CreatedAt,
 		)
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// newTestDB runs the migrations in a new schema of the database in
+// TEST_DB_DSN, which needs the citext extension, and drops the schema when
+// the test ends. Tests that need it are skipped when TEST_DB_DSN isn't set
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the search path is set on the connection, so there must only be one
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxIdleTime(0)
+	db.SetConnMaxLifetime(0)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		db.Exec(`SET search_path TO public`)
+		db.Exec(`DROP SCHEMA IF EXISTS ` + schema + ` CASCADE`)
+		db.Close()
+	})
+
+	_, err = db.Exec(`CREATE SCHEMA ` + schema + `; SET search_path TO ` + schema + `, public`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob("../../migrations/*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(migration)); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return db
+}
+
+// insertTestUser adds an activated user named name
+func insertTestUser(t *testing.T, db *sql.DB, name string) *User {
+	t.Helper()
+
+	user := &User{Username: name, Email: name + "@example.com", Activated: true}
+	user.Password.hash = []byte("not a real hash")
+	if err := (UserModel{DB: db}).Insert(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// insertTestQuote adds a quote by the user
+func insertTestQuote(t *testing.T, db *sql.DB, userID int64, content string, status string, visibility string, tags ...string) *Quote {
+	t.Helper()
+
+	quote := &Quote{UserID: userID, Content: content, Status: status, Visibility: visibility, Tags: tags}
+	if err := (QuoteModel{DB: db}).Insert(quote); err != nil {
+		t.Fatal(err)
+	}
+	return quote
+}
@@ -51,8 +51,8 @@ func generateToken(userID int64, ttl time.Duration, scope string) (*Token, error
 
 // Validate the token the client sends back to us to be 26 bytes long
 func ValidateTokenPlaintext(v *validator.Validator, tokenPlaintext string) {
-	v.Check(tokenPlaintext != "", "token", "must be provided")
-	v.Check(len(tokenPlaintext) == 26, "token", "must be 26 bytes long")
+	v.Check(tokenPlaintext != "", "token", "required")
+	v.Check(len(tokenPlaintext) == 26, "token", "exact_bytes", 26)
 }
 
 // Our access to the database
@@ -7,6 +7,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/aiycoleman/Study-Mate/internal/validator"
@@ -23,6 +24,24 @@ type User struct {
 	Activated bool      `json:"activated"`
 	Version   int       `json:"-"`
 	CreatedAt time.Time `json:"created_at"`
+	Preferences
+}
+
+// Preferences holds the profile settings used to interpret dates for a user
+type Preferences struct {
+	TimeZone       string `json:"time_zone"`
+	Locale         string `json:"locale"`
+	FirstDayOfWeek int    `json:"first_day_of_week"` // 0 is Sunday, 1 is Monday, ...
+}
+
+// Location returns the user's time zone, falling back to UTC when it is
+// missing or no longer valid
+func (p Preferences) Location() *time.Location {
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil || p.TimeZone == "" || p.TimeZone == "Local" {
+		return time.UTC
+	}
+	return loc
 }
 
 type publicUser struct {
@@ -64,23 +83,45 @@ func (p *password) Matches(plaintextPassword string) (bool, error) {
 	return true, nil
 }
 
+// Locales look like "en" or "es-MX"
+var LocaleRX = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// Check that the time zone is a valid IANA name. "Local" is rejected since
+// it means nothing to the database
+func ValidateTimeZone(v *validator.Validator, key string, tz string) {
+	v.Check(tz != "", key, "required")
+	if tz == "" {
+		return
+	}
+	_, err := time.LoadLocation(tz)
+	v.Check(err == nil && tz != "Local", key, "invalid_time_zone")
+}
+
+// validate the profile preferences
+func ValidatePreferences(v *validator.Validator, p Preferences) {
+	ValidateTimeZone(v, "time_zone", p.TimeZone)
+	v.Check(p.Locale != "", "locale", "required")
+	v.Check(validator.Matches(p.Locale, LocaleRX), "locale", "invalid_locale")
+	v.Check(p.FirstDayOfWeek >= 0 && p.FirstDayOfWeek <= 6, "first_day_of_week", "invalid_day_of_week")
+}
+
 // validate  email address
 func ValidateEmail(v *validator.Validator, email string) {
-	v.Check(email != "", "email", "must be provided")
-	v.Check(validator.Matches(email, validator.EmailRX), "email", "must be provided")
+	v.Check(email != "", "email", "required")
+	v.Check(validator.Matches(email, validator.EmailRX), "email", "invalid_email")
 }
 
 // check if password provided is valid
 func ValidatePasswordPlaintext(v *validator.Validator, password string) {
-	v.Check(password != "", "password", "must be provided")
-	v.Check(len(password) >= 8, "password", "must be at least 8 bytes long")
-	v.Check(len(password) <= 12, "password", "must not be more than 72 bytes long")
+	v.Check(password != "", "password", "required")
+	v.Check(len(password) >= 8, "password", "min_bytes", 8)
+	v.Check(len(password) <= 12, "password", "max_bytes", 72)
 }
 
 // validate user
 func ValidateUser(v *validator.Validator, user *User) {
-	v.Check(user.Username != "", "username", "must be provided")
-	v.Check(len(user.Username) <= 200, "username", "must not be more than 200 bytes long")
+	v.Check(user.Username != "", "username", "required")
+	v.Check(len(user.Username) <= 200, "username", "max_bytes", 200)
 
 	// validate email for user
 	ValidateEmail(v, user.Email)
@@ -108,7 +149,7 @@ func (u UserModel) Insert(user *User) error {
 	query := `
 	INSERT INTO users (username, email, password_hash, activated) 
 	VALUES ($1, $2, $3, $4)
-	RETURNING id, created_at, version
+	RETURNING id, created_at, version, time_zone, locale, first_day_of_week
    `
 	args := []any{user.Username, user.Email, user.Password.hash, user.Activated}
 
@@ -116,7 +157,8 @@ func (u UserModel) Insert(user *User) error {
 	defer cancel()
 
 	// if an email address already exists we will get a pq error message
-	err := u.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Version)
+	err := u.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Version,
+		&user.TimeZone, &user.Locale, &user.FirstDayOfWeek)
 
 	if err != nil {
 		switch {
@@ -135,7 +177,8 @@ func (u UserModel) Insert(user *User) error {
 func (u UserModel) GetByEmail(email string) (*User, error) {
 
 	query := `
-		SELECT id, created_at, username, email, password_hash, activated, version
+		SELECT id, created_at, username, email, password_hash, activated, version,
+		       time_zone, locale, first_day_of_week
 		FROM users
 		WHERE email = $1
 	   `
@@ -152,6 +195,9 @@ func (u UserModel) GetByEmail(email string) (*User, error) {
 		&user.Password.hash,
 		&user.Activated,
 		&user.Version,
+		&user.TimeZone,
+		&user.Locale,
+		&user.FirstDayOfWeek,
 	)
 
 	if err != nil {
@@ -211,7 +257,8 @@ func (u UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
 
 	// We will do a join- I hope you still remember how to do a join
 	query := `
-		SELECT users.id, users.created_at, users.username,users.email, users.password_hash, users.activated, users.version
+		SELECT users.id, users.created_at, users.username,users.email, users.password_hash, users.activated, users.version,
+		       users.time_zone, users.locale, users.first_day_of_week
 		FROM users
 		INNER JOIN tokens
 		ON users.id = tokens.user_id
@@ -232,6 +279,9 @@ func (u UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
 		&user.Password.hash,
 		&user.Activated,
 		&user.Version,
+		&user.TimeZone,
+		&user.Locale,
+		&user.FirstDayOfWeek,
 	)
 
 	if err != nil {
@@ -282,19 +332,19 @@ func (u UserModel) GetAll(id int64, username, email string, filters Filters) ([]
 	query := fmt.Sprintf(`
         SELECT COUNT(*) OVER(), username, email, created_at
         FROM users
-        WHERE (to_tsvector('simple', username) @@ plainto_tsquery('simple', $1) OR $1 = '')
+        WHERE %s
         ORDER BY %s %s, id ASC
         LIMIT $2 OFFSET $3`,
-		filters.sortColumn(), filters.sortDirection())
+		fuzzyMatch("username", "$1", fuzzyNameThreshold), filters.sortColumn(), filters.sortDirection())
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := u.DB.QueryContext(ctx, query, username, filters.PageSize, (filters.Page-1)*filters.PageSize)
+	rows, done, err := fuzzyQuery(ctx, u.DB, query, username, filters.PageSize, (filters.Page-1)*filters.PageSize)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
-	defer rows.Close()
+	defer done()
 
 	totalRecords := 0
 	users := []*publicUser{}
@@ -328,7 +378,8 @@ func (u UserModel) GetByID(id int64) (*User, error) {
 	}
 
 	query := `
-		SELECT id, username, email, password_hash, activated, version, created_at
+		SELECT id, username, email, password_hash, activated, version, created_at,
+		       time_zone, locale, first_day_of_week
 		FROM users
 		WHERE id = $1
 	`
@@ -346,6 +397,9 @@ func (u UserModel) GetByID(id int64) (*User, error) {
 		&user.Activated,
 		&user.Version,
 		&user.CreatedAt,
+		&user.TimeZone,
+		&user.Locale,
+		&user.FirstDayOfWeek,
 	)
 
 	if err != nil {
@@ -410,6 +464,39 @@ func (u UserModel) Delete(id int64) error {
 	return nil
 }
 
+// UpdatePreferences saves the user's profile preferences
+func (u UserModel) UpdatePreferences(user *User) error {
+	query := `
+		UPDATE users
+		SET time_zone = $1, locale = $2, first_day_of_week = $3, version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING version
+	`
+
+	args := []any{
+		user.TimeZone,
+		user.Locale,
+		user.FirstDayOfWeek,
+		user.ID,
+		user.Version,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := u.DB.QueryRowContext(ctx, query, args...).Scan(&user.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
 // UpdatePassword changes a user’s password and increments version.
 func (u UserModel) UpdatePassword(id int64, newPassword string) error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
@@ -0,0 +1,78 @@
+// Filename: internal/i18n/en.go
+package i18n
+
+var en = map[string]string{
+	// error responses
+	"server_error":            "the server encountered a problem and could not process your request",
+	"not_found":               "the requested resource could not be found",
+	"method_not_allowed":      "the %s method is not supported for this resource",
+	"rate_limit_exceeded":     "rate limit exceeded",
+	"edit_conflict":           "unable to update the record due to an edit conflict, please try again",
+	"invalid_credentials":     "invalid authentication credentials",
+	"invalid_auth_token":      "invalid or missing authentication token",
+	"not_permitted":           "your user account doesn't have the necessary permissions to access this resource",
+	"duplicate_role":          "User has already been assigned the '%s' role",
+	"authentication_required": "you must be authenticated to access this resource",
+	"inactive_account":        "your user account must be activated to access this resource",
+
+	// validation errors
+	"required":                  "must be provided",
+	"integer":                   "must be an integer value",
+	"boolean":                   "must be 'true' or 'false'",
+	"invalid_date":              "must be a valid date (YYYY-MM-DD)",
+	"greater_than_zero":         "must be greater than zero",
+	"min_value":                 "must be at least %d",
+	"max_value":                 "must not be more than %d",
+	"not_negative":              "must not be negative",
+	"min_bytes":                 "must be at least %d bytes long",
+	"max_bytes":                 "must not be more than %d bytes long",
+	"exact_bytes":               "must be %d bytes long",
+	"max_items":                 "must not contain more than %d items",
+	"max_item_bytes":            "must not contain items more than %d bytes long",
+	"one_of":                    "must be one of: %s",
+	"invalid_sort":              "invalid sort value",
+	"invalid_user_id":           "must be a valid user ID",
+	"invalid_email":             "must be a valid email address",
+	"duplicate_email":           "a user with this email address already exists",
+	"invalid_token":             "invalid or expired activation token",
+	"invalid_time_zone":         "must be a valid IANA time zone",
+	"invalid_locale":            "must be a language code such as en or es-MX",
+	"invalid_day_of_week":       "must be between 0 (Sunday) and 6 (Saturday)",
+	"after_end_date":            "must not be after to",
+	"range_too_long":            "range must not be longer than %d days",
+	"before_start":              "must be after the start time",
+	"reminder_out_of_range":     "must be between 1 minute and 1 week before the start",
+	"in_future":                 "must not be in the future",
+	"outside_period":            "must be in the current period",
+	"not_recurring":             "the goal must be recurring",
+	"already_checked_in":        "already checked in on this day",
+	"recurring_only":            "must only be provided for a recurring goal",
+	"not_for_recurring":         "must not be provided for a recurring goal",
+	"more_than_period_days":     "must not be more than the days in the period",
+	"required_with_subject":     "must be provided when the goal is linked to a subject or tag",
+	"invalid_unsubscribe_token": "invalid unsubscribe link",
+	"unknown_channel":           "is not a notification channel",
+	"mandatory_notification":    "security notifications can't be turned off",
+	"invalid_time_of_day":       "must be a time of day like 07:00",
+	"same_as_start":             "must not be the same as the start",
+	"own_quote":                 "you can't report your own quote",
+	"follow_self":               "you can't follow yourself",
+	"invalid_tag":               "must be lowercase letters, digits and dashes, up to 30 characters",
+	"unsupported_import_format": "the file must be CSV (text/csv) or JSON Lines (application/jsonl)",
+	"missing_column":            "the file must have this column",
+	"malformed_row":             "could not be read",
+	"below_min_minutes":         "must not be less than min_minutes",
+
+	// unsubscribe page
+	"unsubscribe_reminders_question":  "Stop getting reminder emails?",
+	"unsubscribe_digests_question":    "Stop getting the weekly digest?",
+	"unsubscribe_moderation_question": "Stop getting emails about moderation decisions?",
+	"unsubscribe_follows_question":    "Stop getting emails about new followers?",
+	"unsubscribe_button":              "Unsubscribe",
+
+	// confirmations
+	"unsubscribed_reminders":  "you will no longer get reminder emails",
+	"unsubscribed_digests":    "you will no longer get the weekly digest",
+	"unsubscribed_moderation": "you will no longer get emails about moderation decisions",
+	"unsubscribed_follows":    "you will no longer get emails about new followers",
+}
@@ -0,0 +1,78 @@
+// Filename: internal/i18n/es.go
+package i18n
+
+var es = map[string]string{
+	// error responses
+	"server_error":            "el servidor tuvo un problema y no pudo procesar tu solicitud",
+	"not_found":               "no se encontró el recurso solicitado",
+	"method_not_allowed":      "el método %s no está permitido para este recurso",
+	"rate_limit_exceeded":     "se superó el límite de solicitudes",
+	"edit_conflict":           "no se pudo actualizar el registro por un conflicto de edición, inténtalo de nuevo",
+	"invalid_credentials":     "credenciales de autenticación no válidas",
+	"invalid_auth_token":      "el token de autenticación no es válido o falta",
+	"not_permitted":           "tu cuenta no tiene los permisos necesarios para acceder a este recurso",
+	"duplicate_role":          "El usuario ya tiene asignado el rol '%s'",
+	"authentication_required": "debes iniciar sesión para acceder a este recurso",
+	"inactive_account":        "tu cuenta debe estar activada para acceder a este recurso",
+
+	// validation errors
+	"required":                  "es obligatorio",
+	"integer":                   "debe ser un número entero",
+	"boolean":                   "debe ser 'true' o 'false'",
+	"invalid_date":              "debe ser una fecha válida (AAAA-MM-DD)",
+	"greater_than_zero":         "debe ser mayor que cero",
+	"min_value":                 "debe ser al menos %d",
+	"max_value":                 "no debe ser mayor que %d",
+	"not_negative":              "no debe ser negativo",
+	"min_bytes":                 "debe tener al menos %d bytes",
+	"max_bytes":                 "no debe tener más de %d bytes",
+	"exact_bytes":               "debe tener %d bytes",
+	"max_items":                 "no debe contener más de %d elementos",
+	"max_item_bytes":            "no debe contener elementos de más de %d bytes",
+	"one_of":                    "debe ser uno de: %s",
+	"invalid_sort":              "valor de ordenación no válido",
+	"invalid_user_id":           "debe ser un ID de usuario válido",
+	"invalid_email":             "debe ser una dirección de correo válida",
+	"duplicate_email":           "ya existe un usuario con este correo electrónico",
+	"invalid_token":             "el token de activación no es válido o ha caducado",
+	"invalid_time_zone":         "debe ser una zona horaria IANA válida",
+	"invalid_locale":            "debe ser un código de idioma como en o es-MX",
+	"invalid_day_of_week":       "debe estar entre 0 (domingo) y 6 (sábado)",
+	"after_end_date":            "no debe ser posterior a to",
+	"range_too_long":            "el rango no debe superar los %d días",
+	"before_start":              "debe ser posterior a la hora de inicio",
+	"reminder_out_of_range":     "debe estar entre 1 minuto y 1 semana antes del inicio",
+	"in_future":                 "no debe estar en el futuro",
+	"outside_period":            "debe estar dentro del periodo actual",
+	"not_recurring":             "la meta debe ser recurrente",
+	"already_checked_in":        "ya registraste este día",
+	"recurring_only":            "solo se permite en una meta recurrente",
+	"not_for_recurring":         "no se permite en una meta recurrente",
+	"more_than_period_days":     "no debe superar los días del periodo",
+	"required_with_subject":     "es obligatorio cuando la meta está vinculada a una materia o etiqueta",
+	"invalid_unsubscribe_token": "el enlace para darte de baja no es válido",
+	"unknown_channel":           "no es un canal de notificaciones",
+	"mandatory_notification":    "las notificaciones de seguridad no se pueden desactivar",
+	"invalid_time_of_day":       "debe ser una hora del día como 07:00",
+	"same_as_start":             "no debe ser igual al inicio",
+	"own_quote":                 "no puedes denunciar tu propia cita",
+	"follow_self":               "no puedes seguirte a ti mismo",
+	"invalid_tag":               "debe tener letras minúsculas, dígitos y guiones, hasta 30 caracteres",
+	"unsupported_import_format": "el archivo debe ser CSV (text/csv) o JSON Lines (application/jsonl)",
+	"missing_column":            "el archivo debe tener esta columna",
+	"malformed_row":             "no se pudo leer",
+	"below_min_minutes":         "no debe ser menor que min_minutes",
+
+	// unsubscribe page
+	"unsubscribe_reminders_question":  "¿Dejar de recibir correos de recordatorio?",
+	"unsubscribe_digests_question":    "¿Dejar de recibir el resumen semanal?",
+	"unsubscribe_moderation_question": "¿Dejar de recibir correos sobre decisiones de moderación?",
+	"unsubscribe_follows_question":    "¿Dejar de recibir correos sobre nuevos seguidores?",
+	"unsubscribe_button":              "Darme de baja",
+
+	// confirmations
+	"unsubscribed_reminders":  "ya no recibirás correos de recordatorio",
+	"unsubscribed_digests":    "ya no recibirás el resumen semanal",
+	"unsubscribed_moderation": "ya no recibirás correos sobre decisiones de moderación",
+	"unsubscribed_follows":    "ya no recibirás correos sobre nuevos seguidores",
+}
@@ -0,0 +1,92 @@
+// Filename: internal/i18n/i18n.go
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is used when nothing better is known about the reader
+const Default = "en"
+
+// catalogs holds the messages for every supported language, keyed by
+// message ID. Messages may contain fmt verbs that T fills in
+var catalogs = map[string]map[string]string{
+	"en": en,
+	"es": es,
+}
+
+// T returns the message with the given ID in lang. Missing translations fall
+// back to English, and unknown IDs are returned as they are
+func T(lang, id string, args ...any) string {
+	message, ok := catalogs[lang][id]
+	if !ok {
+		message, ok = catalogs[Default][id]
+		if !ok {
+			return id
+		}
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Base strips the region from a locale, so "es-MX" becomes "es"
+func Base(locale string) string {
+	base, _, _ := strings.Cut(locale, "-")
+	base, _, _ = strings.Cut(base, "_")
+	return strings.ToLower(strings.TrimSpace(base))
+}
+
+// Supported returns the catalog language for a locale such as "es-MX", and
+// false if there is no catalog for it
+func Supported(locale string) (string, bool) {
+	lang := Base(locale)
+	_, ok := catalogs[lang]
+	return lang, ok
+}
+
+// Negotiate picks the supported language the client prefers most from an
+// Accept-Language header such as "es-MX,es;q=0.9,en;q=0.8". It returns ""
+// when none of the languages are supported
+func Negotiate(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, ok := Supported(tag)
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	// the header order breaks ties between equal weights
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].lang
+}
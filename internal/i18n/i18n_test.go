@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCatalogsHaveEveryMessage(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for id, message := range catalogs[Default] {
+			translated, ok := catalog[id]
+			if !ok {
+				t.Errorf("%s: missing message %q", lang, id)
+				continue
+			}
+			// the arguments have to line up with the English message
+			if strings.Count(translated, "%") != strings.Count(message, "%") {
+				t.Errorf("%s: message %q has different format verbs", lang, id)
+			}
+		}
+		for id := range catalog {
+			if _, ok := catalogs[Default][id]; !ok {
+				t.Errorf("%s: message %q is not in the English catalog", lang, id)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		lang string
+		id   string
+		args []any
+		want string
+	}{
+		{"en", "required", nil, "must be provided"},
+		{"es", "required", nil, "es obligatorio"},
+		{"es", "max_bytes", []any{255}, "no debe tener más de 255 bytes"},
+		{"fr", "required", nil, "must be provided"},
+		{"es", "no_such_message", nil, "no_such_message"},
+	}
+
+	for _, tt := range tests {
+		got := T(tt.lang, tt.id, tt.args...)
+		if got != tt.want {
+			t.Errorf("T(%q, %q) = %q; want %q", tt.lang, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"es", "es"},
+		{"es-MX", "es"},
+		{"fr-FR, de;q=0.9", ""},
+		{"fr-FR, es;q=0.8, en;q=0.9", "en"},
+		{"en;q=0.5, es-419", "es"},
+		{"es;q=0, en", "en"},
+		{"de, es_ES", "es"},
+		{"en;q=bad, es;q=0.1", "es"},
+	}
+
+	for _, tt := range tests {
+		got := Negotiate(tt.header)
+		if got != tt.want {
+			t.Errorf("Negotiate(%q) = %q; want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	when := time.Date(2025, time.December, 1, 9, 0, 0, 0, time.UTC)
+
+	if got := FormatTime("en", when); got != "Monday 1 December, 09:00 UTC" {
+		t.Errorf("en: got %q", got)
+	}
+	if got := FormatTime("es-MX", when); got != "lunes 1 de diciembre, 09:00 UTC" {
+		t.Errorf("es-MX: got %q", got)
+	}
+}
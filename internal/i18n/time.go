@@ -0,0 +1,23 @@
+// Filename: internal/i18n/time.go
+package i18n
+
+import (
+	"fmt"
+	"time"
+)
+
+var esWeekdays = [...]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"}
+
+var esMonths = [...]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"}
+
+// FormatTime writes a date and time for emails in the reader's locale, for
+// example "Monday 1 December, 09:00 GMT" or "lunes 1 de diciembre, 09:00 GMT".
+// time.Format only knows English names, so Spanish builds its own
+func FormatTime(locale string, t time.Time) string {
+	lang, _ := Supported(locale)
+	if lang != "es" {
+		return t.Format("Monday 2 January, 15:04 MST")
+	}
+
+	return fmt.Sprintf("%s %d de %s, %s", esWeekdays[t.Weekday()], t.Day(), esMonths[t.Month()-1], t.Format("15:04 MST"))
+}
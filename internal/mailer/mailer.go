@@ -5,9 +5,10 @@ import (
 	"bytes"
 	"embed"
 	"html/template"
-	"time"
+	"io/fs"
+	"strings"
 
-	"github.com/go-mail/mail/v2"
+	"github.com/aiycoleman/Study-Mate/internal/i18n"
 )
 
 // don't need a separate server for serving static files
@@ -15,72 +16,103 @@ import (
 //go:embed templates/*
 var templateFS embed.FS // embed the files from templates into our program
 
-type Mailer struct {
-	dialer *mail.Dialer // connection to the SMTP server
-	sender string       // who is sending the email
+// Message is a rendered email, ready for a transport to deliver
+type Message struct {
+	To      string
+	From    string
+	Subject string
+	Plain   string
+	HTML    string
+}
+
+// Transport delivers rendered messages, for example over SMTP or into a
+// directory during development
+type Transport interface {
+	Send(msg Message) error
 }
 
-// Configure a SMTP connection instance using our credentials from Mailtrap
-func New(host string, port int, username, password, sender string) Mailer {
-	dialer := mail.NewDialer(host, port, username, password)
-	dialer.Timeout = 5 * time.Second
+type Mailer struct {
+	transport Transport // how messages are delivered
+	sender    string    // who is sending the email
+}
 
+// Create a mailer that renders the templates and hands the messages to the
+// transport
+func New(transport Transport, sender string) Mailer {
 	return Mailer{
-		dialer: dialer,
-		sender: sender,
+		transport: transport,
+		sender:    sender,
 	}
 }
 
-// Send the email to the user. The data parameter is for the dynamic data
-// to inject into the template
-func (m Mailer) Send(recipient, templateFile string, data any) error {
-	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
+// localizedTemplate returns the translation of templateFile for the locale,
+// so "user_welcome.tmpl" becomes "user_welcome.es.tmpl" for "es-MX". The
+// template itself is the English version and the fallback
+func localizedTemplate(templateFile, locale string) string {
+	lang, ok := i18n.Supported(locale)
+	if !ok || lang == i18n.Default {
+		return templateFile
+	}
 
+	name := strings.TrimSuffix(templateFile, ".tmpl") + "." + lang + ".tmpl"
+	if _, err := fs.Stat(templateFS, "templates/"+name); err != nil {
+		return templateFile
+	}
+	return name
+}
+
+// Render fills in the subject, plainBody and htmlBody parts of the template
+// for the recipient, in their locale when there is a translation. The data
+// parameter is for the dynamic data to inject into the template
+func (m Mailer) Render(recipient, templateFile, locale string, data any) (Message, error) {
+	templateFile = localizedTemplate(templateFile, locale)
+	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
 	if err != nil {
-		return err
+		return Message{}, err
 	}
-	println("Template parsed successfully", templateFile)
 
 	// fill in the subject part
 	subject := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(subject, "subject", data)
 	if err != nil {
-		return err
+		return Message{}, err
 	}
 
 	// fill in the plainBody part
 	plainBody := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(plainBody, "plainBody", data)
 	if err != nil {
-		return err
+		return Message{}, err
 	}
 
 	// fill in the htmlBody part
 	htmlBody := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
 	if err != nil {
-		return err
+		return Message{}, err
 	}
 
-	// Craft the message from the parts above
-	msg := mail.NewMessage()
-	msg.SetHeader("To", recipient)
-	msg.SetHeader("From", m.sender)
-	msg.SetHeader("Subject", subject.String())
-	msg.SetBody("text/plain", plainBody.String())
-	msg.AddAlternative("text/html", htmlBody.String())
-
-	// send the message. Try to do this at most 3 times before giving up
-	for i := 1; i <= 3; i++ {
-		err = m.dialer.DialAndSend(msg)
-		// If everything worked, return nil.
-		if err == nil {
-			return nil
-		}
-
-		// If it didn't work, sleep for a short time and retry.
-		time.Sleep(500 * time.Millisecond)
+	return Message{
+		To:      recipient,
+		From:    m.sender,
+		Subject: subject.String(),
+		Plain:   plainBody.String(),
+		HTML:    htmlBody.String(),
+	}, nil
+}
+
+// Send the email to the user. Retries are left to the job queue, which
+// backs off between attempts
+func (m Mailer) Send(recipient, templateFile, locale string, data any) error {
+	msg, err := m.Render(recipient, templateFile, locale, data)
+	if err != nil {
+		return err
 	}
 
-	return err // give up
+	return m.Deliver(msg)
+}
+
+// Deliver hands a rendered message to the transport
+func (m Mailer) Deliver(msg Message) error {
+	return m.transport.Send(msg)
 }
@@ -0,0 +1,265 @@
+package mailer
+
+import (
+	"bytes"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Digest data as it comes out of the job queue, after a JSON round trip
+var digestData = map[string]any{
+	"username":          "alice",
+	"weekStart":         "2025-11-24",
+	"weekEnd":           "2025-11-30",
+	"focusedMinutes":    int64(150),
+	"completedSessions": int64(4),
+	"subjects": []any{
+		map[string]any{"subject": "Biology", "minutes": int64(90), "sessions": int64(3)},
+		map[string]any{"subject": "Maths", "minutes": int64(60), "sessions": int64(1)},
+	},
+	"goalsCompleted": []any{map[string]any{"goalID": int64(3), "goalText": "Finish calculus", "targetDate": "2025-12-01"}},
+	"goalsDueSoon":   []any{map[string]any{"goalID": int64(4), "goalText": "Essay draft", "targetDate": "2025-12-03"}},
+	"currentStreak":  int64(5),
+	"unsubscribeURL": "https://api.example.com/v1/unsubscribe?token=1.digests.sig",
+}
+
+const (
+	remindersURL  = "https://api.example.com/v1/unsubscribe?token=1.reminders.sig"
+	moderationURL = "https://api.example.com/v1/unsubscribe?token=1.moderation.sig"
+	followsURL    = "https://api.example.com/v1/unsubscribe?token=1.follows.sig"
+)
+
+// Every template with sample data and text that must appear in each part
+var templateTests = map[string]struct {
+	data    map[string]any
+	subject string
+	plain   []string
+	html    []string
+}{
+	"user_welcome.tmpl": {
+		data:    map[string]any{"userID": 7, "activationToken": "ABCDEFGHIJKLMNOPQRSTUVWXYZ"},
+		subject: "Welcome to Study Mate!",
+		plain:   []string{"your user ID number is 7", `{"token": "ABCDEFGHIJKLMNOPQRSTUVWXYZ"}`},
+		html:    []string{"your user ID number is 7", "ABCDEFGHIJKLMNOPQRSTUVWXYZ"},
+	},
+	"goal_due_soon.tmpl": {
+		data:    map[string]any{"username": "alice", "goalID": 3, "goalText": "Finish calculus", "targetDate": "2025-12-01", "daysLeft": 2, "daysLate": -2, "unsubscribeURL": remindersURL},
+		subject: "Your goal is due in 2 days",
+		plain:   []string{"Hi alice", `"Finish calculus" is due on 2025-12-01`, "GET /v1/goals/3", remindersURL},
+		html:    []string{"Hi alice", "<strong>Finish calculus</strong>", "GET /v1/goals/3"},
+	},
+	"goal_overdue.tmpl": {
+		data:    map[string]any{"username": "alice", "goalID": 3, "goalText": "Finish calculus", "targetDate": "2025-12-01", "daysLeft": -4, "daysLate": 4, "unsubscribeURL": remindersURL},
+		subject: "Your goal is overdue",
+		plain:   []string{"Hi alice", "was due on 2025-12-01, 4 day(s) ago", "PATCH /v1/goals/3"},
+		html:    []string{"Hi alice", "<strong>Finish calculus</strong>", "4 day(s) ago", `href="` + remindersURL + `"`},
+	},
+	"session_reminder.tmpl": {
+		data:    map[string]any{"username": "alice", "sessionID": 9, "title": "Revision", "subject": "Biology", "startTime": "Monday 1 December, 09:00 GMT", "minutesLeft": 10, "hoursLeft": 0, "unsubscribeURL": remindersURL},
+		subject: `"Revision" starts in 10 minutes`,
+		plain:   []string{"Hi alice", `"Revision" (Biology) starts at Monday 1 December, 09:00 GMT`, "GET /v1/study-sessions/9"},
+		html:    []string{"Hi alice", "<strong>Revision</strong> (Biology)", "GET /v1/study-sessions/9", "Unsubscribe from reminder emails"},
+	},
+	"user_welcome.es.tmpl": {
+		data:    map[string]any{"userID": 7, "activationToken": "ABCDEFGHIJKLMNOPQRSTUVWXYZ"},
+		subject: "¡Bienvenido a Study Mate!",
+		plain:   []string{"tu número de ID de usuario es 7", `{"token": "ABCDEFGHIJKLMNOPQRSTUVWXYZ"}`},
+		html:    []string{"tu número de ID de usuario es 7", "ABCDEFGHIJKLMNOPQRSTUVWXYZ"},
+	},
+	"goal_due_soon.es.tmpl": {
+		data:    map[string]any{"username": "alice", "goalID": 3, "goalText": "Terminar cálculo", "targetDate": "2025-12-01", "daysLeft": 1, "daysLate": -1, "unsubscribeURL": remindersURL},
+		subject: "Tu meta vence mañana",
+		plain:   []string{"Hola alice", `"Terminar cálculo" vence el 2025-12-01`, "GET /v1/goals/3", remindersURL},
+		html:    []string{"Hola alice", "<strong>Terminar cálculo</strong>", "GET /v1/goals/3"},
+	},
+	"goal_overdue.es.tmpl": {
+		data:    map[string]any{"username": "alice", "goalID": 3, "goalText": "Terminar cálculo", "targetDate": "2025-12-01", "daysLeft": -4, "daysLate": 4, "unsubscribeURL": remindersURL},
+		subject: "Tu meta está vencida",
+		plain:   []string{"Hola alice", "vencía el 2025-12-01, hace 4 día(s)", "PATCH /v1/goals/3"},
+		html:    []string{"Hola alice", "<strong>Terminar cálculo</strong>", "hace 4 día(s)"},
+	},
+	"session_reminder.es.tmpl": {
+		data:    map[string]any{"username": "alice", "sessionID": 9, "title": "Repaso", "subject": "Biología", "startTime": "lunes 1 de diciembre, 09:00 GMT", "minutesLeft": 90, "hoursLeft": 2, "unsubscribeURL": remindersURL},
+		subject: `"Repaso" empieza en unas 2 hora(s)`,
+		plain:   []string{"Hola alice", `"Repaso" (Biología) empieza el lunes 1 de diciembre, 09:00 GMT`, "GET /v1/study-sessions/9", remindersURL},
+		html:    []string{"Hola alice", "<strong>Repaso</strong> (Biología)", "GET /v1/study-sessions/9"},
+	},
+	"quote_moderated.tmpl": {
+		data:    map[string]any{"username": "alice", "quoteID": 12, "content": "Stay curious", "status": "rejected", "reason": "Not a quote", "unsubscribeURL": moderationURL},
+		subject: "Your quote was rejected",
+		plain:   []string{"Hi alice", `"Stay curious"`, "Reason: Not a quote", "GET /v1/quotes/12", "Editing it sends it back for review", moderationURL},
+		html:    []string{"Hi alice", "<blockquote>Stay curious</blockquote>", "<strong>Not a quote</strong>", "Unsubscribe from moderation emails"},
+	},
+	"quote_moderated.es.tmpl": {
+		data:    map[string]any{"username": "alice", "quoteID": 12, "content": "Sigue aprendiendo", "status": "approved", "reason": "", "unsubscribeURL": moderationURL},
+		subject: "Tu cita fue aprobada",
+		plain:   []string{"Hola alice", "Tu cita ya es visible para todos", `"Sigue aprendiendo"`, "GET /v1/quotes/12", moderationURL},
+		html:    []string{"Hola alice", "<blockquote>Sigue aprendiendo</blockquote>"},
+	},
+	"new_follower.tmpl": {
+		data:    map[string]any{"username": "alice", "followerName": "bob", "unsubscribeURL": followsURL},
+		subject: "bob wants to follow you",
+		plain:   []string{"Hi alice", "bob asked to follow you", "Once you approve the request", followsURL},
+		html:    []string{"Hi alice", "<strong>bob</strong> asked to follow you", "Unsubscribe from new follower emails"},
+	},
+	"new_follower.es.tmpl": {
+		data:    map[string]any{"username": "alice", "followerName": "bob", "unsubscribeURL": followsURL},
+		subject: "bob quiere seguirte",
+		plain:   []string{"Hola alice", "bob pidió seguirte", "Cuando apruebes la solicitud", followsURL},
+		html:    []string{"Hola alice", "<strong>bob</strong> pidió seguirte", "Darte de baja de los correos de nuevos seguidores"},
+	},
+	"weekly_digest.tmpl": {
+		data:    digestData,
+		subject: "Your study week: 150 minutes focused",
+		plain:   []string{"Hi alice", "from 2025-11-24 to 2025-11-30", "150 minutes over 4 completed session(s)", "- Biology: 90 minutes in 3 session(s)", "- Finish calculus", "- Essay draft (due 2025-12-03)", "5 day streak", "https://api.example.com/v1/unsubscribe?token=1.digests.sig"},
+		html:    []string{"Hi alice", "<td>Biology</td><td align=\"right\">90</td>", "<li>Finish calculus</li>", `href="https://api.example.com/v1/unsubscribe?token=1.digests.sig"`},
+	},
+	"weekly_digest.es.tmpl": {
+		data:    digestData,
+		subject: "Tu semana de estudio: 150 minutos de concentración",
+		plain:   []string{"Hola alice", "del 2025-11-24 al 2025-11-30", "- Biology: 90 minutos en 3 sesión(es)", "- Essay draft (vence el 2025-12-03)", "racha de 5 día(s)", "https://api.example.com/v1/unsubscribe?token=1.digests.sig"},
+		html:    []string{"Hola alice", "<li>Finish calculus</li>", "Darte de baja del resumen semanal"},
+	},
+}
+
+func TestSend_RendersEveryTemplate(t *testing.T) {
+	for name, tt := range templateTests {
+		t.Run(name, func(t *testing.T) {
+			transport := &MemoryTransport{}
+			m := New(transport, "Study Mate <no-reply@example.com>")
+
+			err := m.Send("alice@example.com", name, "", tt.data)
+			if err != nil {
+				t.Fatalf("send: %v", err)
+			}
+
+			messages := transport.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("expected 1 message; got %d", len(messages))
+			}
+			msg := messages[0]
+
+			if msg.To != "alice@example.com" || msg.From != "Study Mate <no-reply@example.com>" {
+				t.Errorf("unexpected addresses: to=%q from=%q", msg.To, msg.From)
+			}
+			if msg.Subject != tt.subject {
+				t.Errorf("subject = %q; want %q", msg.Subject, tt.subject)
+			}
+			for _, want := range tt.plain {
+				if !strings.Contains(msg.Plain, want) {
+					t.Errorf("plain body does not contain %q:\n%s", want, msg.Plain)
+				}
+			}
+			for _, want := range tt.html {
+				if !strings.Contains(msg.HTML, want) {
+					t.Errorf("HTML body does not contain %q:\n%s", want, msg.HTML)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplatesAllHaveTests(t *testing.T) {
+	names, err := fs.Glob(templateFS, "templates/*.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range names {
+		if _, ok := templateTests[filepath.Base(name)]; !ok {
+			t.Errorf("template %s has no rendering test", name)
+		}
+	}
+}
+
+func TestSend_PicksTemplateForLocale(t *testing.T) {
+	tests := []struct {
+		locale  string
+		subject string
+	}{
+		{"", "Welcome to Study Mate!"},
+		{"en", "Welcome to Study Mate!"},
+		{"es", "¡Bienvenido a Study Mate!"},
+		{"es-MX", "¡Bienvenido a Study Mate!"},
+		{"fr", "Welcome to Study Mate!"}, // no translation, falls back to English
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			m := New(&MemoryTransport{}, "no-reply@example.com")
+
+			msg, err := m.Render("alice@example.com", "user_welcome.tmpl", tt.locale, templateTests["user_welcome.tmpl"].data)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if msg.Subject != tt.subject {
+				t.Errorf("subject = %q; want %q", msg.Subject, tt.subject)
+			}
+		})
+	}
+}
+
+func TestSend_UnknownTemplate(t *testing.T) {
+	transport := &MemoryTransport{}
+	m := New(transport, "no-reply@example.com")
+
+	err := m.Send("alice@example.com", "missing.tmpl", "es", nil)
+	if err == nil {
+		t.Fatal("expected an error for a missing template")
+	}
+	if len(transport.Messages()) != 0 {
+		t.Fatal("expected nothing to be sent")
+	}
+}
+
+func TestFileTransport_WritesEML(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := New(&FileTransport{Dir: dir}, "no-reply@example.com")
+
+	for range 2 {
+		err := m.Send("alice@example.com", "user_welcome.tmpl", "en", templateTests["user_welcome.tmpl"].data)
+		if err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 .eml files; got %d", len(files))
+	}
+
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"To: alice@example.com", "Subject: Welcome to Study Mate!", "text/plain", "text/html"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf(".eml file does not contain %q", want)
+		}
+	}
+}
+
+func TestLogTransport_LeavesOutTheBody(t *testing.T) {
+	var logs bytes.Buffer
+	m := New(LogTransport{Logger: slog.New(slog.NewTextHandler(&logs, nil))}, "no-reply@example.com")
+
+	data := templateTests["user_welcome.tmpl"].data
+	err := m.Send("alice@example.com", "user_welcome.tmpl", "en", data)
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if !strings.Contains(logs.String(), "alice@example.com") {
+		t.Errorf("expected the recipient in the log: %s", logs.String())
+	}
+	if strings.Contains(logs.String(), data["activationToken"].(string)) {
+		t.Errorf("expected the activation token to stay out of the log: %s", logs.String())
+	}
+}
@@ -0,0 +1,109 @@
+// Filename: internal/mailer/transports.go
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-mail/mail/v2"
+)
+
+// mimeMessage builds the multipart message that is sent or written out
+func (msg Message) mimeMessage() *mail.Message {
+	m := mail.NewMessage()
+	m.SetHeader("To", msg.To)
+	m.SetHeader("From", msg.From)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetBody("text/plain", msg.Plain)
+	m.AddAlternative("text/html", msg.HTML)
+	return m
+}
+
+// SMTPTransport sends messages through an SMTP server
+type SMTPTransport struct {
+	dialer *mail.Dialer // connection to the SMTP server
+}
+
+// Configure a SMTP connection instance using our credentials
+func NewSMTPTransport(host string, port int, username, password string) *SMTPTransport {
+	dialer := mail.NewDialer(host, port, username, password)
+	dialer.Timeout = 5 * time.Second
+
+	return &SMTPTransport{dialer: dialer}
+}
+
+func (t *SMTPTransport) Send(msg Message) error {
+	return t.dialer.DialAndSend(msg.mimeMessage())
+}
+
+// FileTransport writes every message to its own .eml file in Dir, which
+// most mail clients can open. Useful during local development
+type FileTransport struct {
+	Dir string
+
+	mu    sync.Mutex
+	count int
+}
+
+func (t *FileTransport) Send(msg Message) error {
+	err := os.MkdirAll(t.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	// the counter keeps file names unique within the same nanosecond
+	t.mu.Lock()
+	t.count++
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().UTC().Format("20060102T150405.000000000"), t.count)
+	t.mu.Unlock()
+
+	file, err := os.Create(filepath.Join(t.Dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = msg.mimeMessage().WriteTo(file)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// LogTransport logs who the messages are for instead of sending them. The
+// bodies are left out, since they carry tokens and signed links
+type LogTransport struct {
+	Logger *slog.Logger
+}
+
+func (t LogTransport) Send(msg Message) error {
+	t.Logger.Info("email", "to", msg.To, "from", msg.From, "subject", msg.Subject)
+	return nil
+}
+
+// MemoryTransport keeps the messages in memory so tests can assert on them
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (t *MemoryTransport) Send(msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = append(t.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the messages sent so far
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Message(nil), t.messages...)
+}
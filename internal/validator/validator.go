@@ -4,20 +4,35 @@ package validator
 import (
 	"regexp"
 	"slices"
+
+	"github.com/aiycoleman/Study-Mate/internal/i18n"
 )
 
 // Regex to check if an email is valid
 var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 
+// FieldError is a failed check. Code is a message ID from the i18n catalog
+// that clients can rely on, and Args fill in the message
+type FieldError struct {
+	Code string
+	Args []any
+}
+
+// Message is a field error as sent to clients
+type Message struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 type Validator struct {
-	Errors map[string]string
+	Errors map[string]FieldError
 }
 
 // Construct a new Validator and return a pointer to it
 // All validation errors go into this one Validtor instance
 func New() *Validator {
 	return &Validator{
-		Errors: make(map[string]string),
+		Errors: make(map[string]FieldError),
 	}
 }
 
@@ -28,18 +43,27 @@ func (v *Validator) IsEmpty() bool {
 
 // Add a new error entry to the Validator's error map
 // Check first if an entry with the same key does not already exist
-func (v *Validator) AddError(key string, message string) {
+func (v *Validator) AddError(key string, code string, args ...any) {
 	_, exists := v.Errors[key]
 	if !exists {
-		v.Errors[key] = message
+		v.Errors[key] = FieldError{Code: code, Args: args}
 	}
 }
 
 // If any validation check returns false, then make an entry into our validator's error map
-func (v *Validator) Check(acceptable bool, key string, message string) {
+func (v *Validator) Check(acceptable bool, key string, code string, args ...any) {
 	if !acceptable {
-		v.AddError(key, message)
+		v.AddError(key, code, args...)
+	}
+}
+
+// Localize returns every error with its code and its message in lang
+func (v *Validator) Localize(lang string) map[string]Message {
+	messages := make(map[string]Message, len(v.Errors))
+	for key, e := range v.Errors {
+		messages[key] = Message{Code: e.Code, Message: i18n.T(lang, e.Code, e.Args...)}
 	}
+	return messages
 }
 
 // Check for permitted values
@@ -0,0 +1,6 @@
+-- Filename: migrations/000010_alter_study_sessions_times.down.sql
+DROP INDEX IF EXISTS study_sessions_user_id_start_time_idx;
+
+ALTER TABLE study_sessions
+    ALTER COLUMN start_time TYPE TIME USING start_time::time,
+    ALTER COLUMN end_time TYPE TIME USING end_time::time;
@@ -0,0 +1,17 @@
+-- Filename: migrations/000010_alter_study_sessions_times.up.sql
+-- start_time and end_time were stored as TIME, so a session had no date and
+-- could not be bucketed per day. Promote them to full timestamps, using the
+-- day the session was created for the existing rows.
+ALTER TABLE study_sessions
+    ALTER COLUMN start_time TYPE timestamp(0) WITH TIME ZONE
+        USING (created_at::date + start_time)::timestamptz,
+    ALTER COLUMN end_time TYPE timestamp(0) WITH TIME ZONE
+        USING (created_at::date + end_time)::timestamptz;
+
+-- A session that ended before it started rolled over midnight
+UPDATE study_sessions
+SET end_time = end_time + INTERVAL '1 day'
+WHERE end_time < start_time;
+
+CREATE INDEX IF NOT EXISTS study_sessions_user_id_start_time_idx
+    ON study_sessions (user_id, start_time);
@@ -0,0 +1,5 @@
+-- Filename: migrations/000011_add_users_preferences.down.sql
+ALTER TABLE users
+    DROP COLUMN IF EXISTS time_zone,
+    DROP COLUMN IF EXISTS locale,
+    DROP COLUMN IF EXISTS first_day_of_week;
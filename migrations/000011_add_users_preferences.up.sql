@@ -0,0 +1,6 @@
+-- Filename: migrations/000011_add_users_preferences.up.sql
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS time_zone text NOT NULL DEFAULT 'UTC',
+    ADD COLUMN IF NOT EXISTS locale text NOT NULL DEFAULT 'en',
+    ADD COLUMN IF NOT EXISTS first_day_of_week smallint NOT NULL DEFAULT 1
+        CHECK (first_day_of_week BETWEEN 0 AND 6);
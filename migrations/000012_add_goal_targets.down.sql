@@ -0,0 +1,10 @@
+-- Filename: migrations/000012_add_goal_targets.down.sql
+ALTER TABLE goals
+    DROP COLUMN IF EXISTS target_minutes,
+    DROP COLUMN IF EXISTS subject,
+    DROP COLUMN IF EXISTS tag;
+
+DROP INDEX IF EXISTS study_sessions_tags_idx;
+
+ALTER TABLE study_sessions
+    DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,12 @@
+-- Filename: migrations/000012_add_goal_targets.up.sql
+ALTER TABLE study_sessions
+    ADD COLUMN IF NOT EXISTS tags text[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS study_sessions_tags_idx ON study_sessions USING GIN (tags);
+
+-- A measurable goal counts the completed minutes of the sessions that match
+-- its subject and/or tag, from when the goal was created to its target date
+ALTER TABLE goals
+    ADD COLUMN IF NOT EXISTS target_minutes integer CHECK (target_minutes > 0),
+    ADD COLUMN IF NOT EXISTS subject text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tag text NOT NULL DEFAULT '';
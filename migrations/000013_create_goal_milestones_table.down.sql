@@ -0,0 +1,2 @@
+-- Filename: migrations/000013_create_goal_milestones_table.down.sql
+DROP TABLE IF EXISTS goal_milestones;
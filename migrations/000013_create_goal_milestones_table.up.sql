@@ -0,0 +1,13 @@
+-- Filename: migrations/000013_create_goal_milestones_table.up.sql
+CREATE TABLE IF NOT EXISTS goal_milestones (
+    milestone_id bigserial PRIMARY KEY,
+    goal_id bigint NOT NULL REFERENCES goals ON DELETE CASCADE,
+    title text NOT NULL,
+    position integer NOT NULL,
+    due_date DATE NOT NULL,
+    is_completed boolean NOT NULL DEFAULT false,
+    completed_at timestamp(0) WITH TIME ZONE,
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS goal_milestones_goal_id_position_idx ON goal_milestones (goal_id, position);
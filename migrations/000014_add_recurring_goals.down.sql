@@ -0,0 +1,12 @@
+-- Filename: migrations/000014_add_recurring_goals.down.sql
+DROP TABLE IF EXISTS goal_period_results;
+
+DROP TABLE IF EXISTS goal_checkins;
+
+DELETE FROM goals WHERE target_date IS NULL;
+
+ALTER TABLE goals
+    DROP COLUMN IF EXISTS goal_type,
+    DROP COLUMN IF EXISTS period,
+    DROP COLUMN IF EXISTS target_count,
+    ALTER COLUMN target_date SET NOT NULL;
@@ -0,0 +1,31 @@
+-- Filename: migrations/000014_add_recurring_goals.up.sql
+-- A recurring goal repeats every period (such as "study 5 days a week") and
+-- has no single target date, so the date becomes optional
+ALTER TABLE goals
+    ADD COLUMN IF NOT EXISTS goal_type text NOT NULL DEFAULT 'one_time' CHECK (goal_type IN ('one_time', 'recurring')),
+    ADD COLUMN IF NOT EXISTS period text CHECK (period IN ('daily', 'weekly', 'monthly')),
+    ADD COLUMN IF NOT EXISTS target_count integer CHECK (target_count > 0),
+    ALTER COLUMN target_date DROP NOT NULL;
+
+-- At most one check-in per goal and day, the day is in the owner's time zone
+CREATE TABLE IF NOT EXISTS goal_checkins (
+    checkin_id bigserial PRIMARY KEY,
+    goal_id bigint NOT NULL REFERENCES goals ON DELETE CASCADE,
+    checkin_date DATE NOT NULL,
+    note text NOT NULL DEFAULT '',
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (goal_id, checkin_date)
+);
+
+-- The outcome of every closed period of a recurring goal. The target count
+-- is copied so later changes to the goal don't rewrite history
+CREATE TABLE IF NOT EXISTS goal_period_results (
+    goal_id bigint NOT NULL REFERENCES goals ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    completed_count integer NOT NULL,
+    target_count integer NOT NULL,
+    succeeded boolean NOT NULL,
+    recorded_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (goal_id, period_start)
+);
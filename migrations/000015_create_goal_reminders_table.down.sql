@@ -0,0 +1,7 @@
+-- Filename: migrations/000015_create_goal_reminders_table.down.sql
+DROP INDEX IF EXISTS goals_open_target_date_idx;
+
+DROP TABLE IF EXISTS goal_reminders;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS goal_reminders;
@@ -0,0 +1,16 @@
+-- Filename: migrations/000015_create_goal_reminders_table.up.sql
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS goal_reminders boolean NOT NULL DEFAULT true;
+
+-- One row per reminder sent. The target date is part of the key so moving
+-- a goal's deadline allows a fresh reminder for the new date
+CREATE TABLE IF NOT EXISTS goal_reminders (
+    goal_id bigint NOT NULL REFERENCES goals ON DELETE CASCADE,
+    kind text NOT NULL CHECK (kind IN ('due_soon', 'overdue')),
+    target_date DATE NOT NULL,
+    sent_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (goal_id, kind, target_date)
+);
+
+CREATE INDEX IF NOT EXISTS goals_open_target_date_idx ON goals (target_date)
+    WHERE is_completed IS NOT TRUE AND target_date IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Filename: migrations/000016_add_session_reminders.down.sql
+DROP TABLE IF EXISTS session_reminders;
+
+DROP INDEX IF EXISTS study_sessions_pending_reminders_idx;
+
+ALTER TABLE study_sessions
+    DROP COLUMN IF EXISTS reminder_offsets;
@@ -0,0 +1,17 @@
+-- Filename: migrations/000016_add_session_reminders.up.sql
+-- Minutes before the start of a session to send each reminder
+ALTER TABLE study_sessions
+    ADD COLUMN IF NOT EXISTS reminder_offsets integer[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS study_sessions_pending_reminders_idx ON study_sessions (start_time)
+    WHERE reminder_offsets <> '{}' AND is_completed IS NOT TRUE;
+
+-- One row per reminder sent. The start time is part of the key so moving
+-- a session re-arms its reminders
+CREATE TABLE IF NOT EXISTS session_reminders (
+    session_id bigint NOT NULL REFERENCES study_sessions ON DELETE CASCADE,
+    offset_minutes integer NOT NULL,
+    start_time timestamp(0) WITH TIME ZONE NOT NULL,
+    sent_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (session_id, offset_minutes, start_time)
+);
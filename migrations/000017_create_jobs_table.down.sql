@@ -0,0 +1,5 @@
+-- Filename: migrations/000017_create_jobs_table.down.sql
+DELETE FROM permissions
+WHERE code = 'jobs:read';
+
+DROP TABLE IF EXISTS jobs;
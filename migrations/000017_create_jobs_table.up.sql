@@ -0,0 +1,25 @@
+-- Filename: migrations/000017_create_jobs_table.up.sql
+-- Work that must survive a restart, such as sending email. Workers claim
+-- pending jobs with FOR UPDATE SKIP LOCKED. A job that keeps failing ends
+-- up dead so it can be looked at instead of being retried forever
+CREATE TABLE IF NOT EXISTS jobs (
+    id bigserial PRIMARY KEY,
+    kind text NOT NULL,
+    payload jsonb NOT NULL DEFAULT '{}',
+    status text NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'dead')),
+    attempts integer NOT NULL DEFAULT 0,
+    max_attempts integer NOT NULL DEFAULT 8 CHECK (max_attempts > 0),
+    run_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_at timestamp(0) WITH TIME ZONE,
+    last_error text NOT NULL DEFAULT '',
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS jobs_pending_run_at_idx ON jobs (run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS jobs_running_locked_at_idx ON jobs (locked_at) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS jobs_status_idx ON jobs (status, id);
+
+INSERT INTO permissions (code)
+VALUES
+   ('jobs:read');
@@ -0,0 +1,8 @@
+-- Filename: migrations/000018_create_weekly_digests_table.down.sql
+DROP TABLE IF EXISTS weekly_digests;
+
+ALTER TABLE goals
+    DROP COLUMN IF EXISTS completed_at;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS weekly_digest;
@@ -0,0 +1,17 @@
+-- Filename: migrations/000018_create_weekly_digests_table.up.sql
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS weekly_digest boolean NOT NULL DEFAULT true;
+
+-- When a goal was completed, so the digest can list last week's goals.
+-- Goals completed before this column existed stay NULL
+ALTER TABLE goals
+    ADD COLUMN IF NOT EXISTS completed_at timestamp(0) WITH TIME ZONE;
+
+-- One row per digest sent. week_start is the Monday of the week the digest
+-- summarizes, in the user's time zone
+CREATE TABLE IF NOT EXISTS weekly_digests (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    week_start DATE NOT NULL,
+    sent_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, week_start)
+);
@@ -0,0 +1,21 @@
+-- Filename: migrations/000019_create_notification_preferences_table.down.sql
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS goal_reminders boolean NOT NULL DEFAULT true,
+    ADD COLUMN IF NOT EXISTS weekly_digest boolean NOT NULL DEFAULT true;
+
+UPDATE users u
+SET goal_reminders = false
+FROM notification_preferences np
+WHERE np.user_id = u.id AND np.channel = 'email' AND np.category = 'reminders' AND NOT np.enabled;
+
+UPDATE users u
+SET weekly_digest = false
+FROM notification_preferences np
+WHERE np.user_id = u.id AND np.channel = 'email' AND np.category = 'digests' AND NOT np.enabled;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_quiet_hours_check,
+    DROP COLUMN IF EXISTS quiet_hours_start,
+    DROP COLUMN IF EXISTS quiet_hours_end;
+
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,31 @@
+-- Filename: migrations/000019_create_notification_preferences_table.up.sql
+-- Which kinds of notification a user wants on each channel. A missing row
+-- means enabled. Security notifications can't be turned off, so they are
+-- never stored
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    channel text NOT NULL CHECK (channel IN ('email')),
+    category text NOT NULL CHECK (category IN ('reminders', 'digests')),
+    enabled boolean NOT NULL,
+    updated_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, channel, category)
+);
+
+-- Quiet hours in the user's time zone. An end before the start wraps past
+-- midnight, e.g. 22:00 to 07:00
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS quiet_hours_start time,
+    ADD COLUMN IF NOT EXISTS quiet_hours_end time,
+    ADD CONSTRAINT users_quiet_hours_check
+        CHECK ((quiet_hours_start IS NULL) = (quiet_hours_end IS NULL) AND quiet_hours_start IS DISTINCT FROM quiet_hours_end);
+
+-- carry over the switches this table replaces
+INSERT INTO notification_preferences (user_id, channel, category, enabled)
+SELECT id, 'email', 'reminders', false FROM users WHERE NOT goal_reminders;
+
+INSERT INTO notification_preferences (user_id, channel, category, enabled)
+SELECT id, 'email', 'digests', false FROM users WHERE NOT weekly_digest;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS goal_reminders,
+    DROP COLUMN IF EXISTS weekly_digest;
@@ -0,0 +1,8 @@
+-- Filename: migrations/000020_create_notifications_table.down.sql
+DELETE FROM notification_preferences WHERE channel = 'in_app';
+
+ALTER TABLE notification_preferences
+    DROP CONSTRAINT IF EXISTS notification_preferences_channel_check,
+    ADD CONSTRAINT notification_preferences_channel_check CHECK (channel IN ('email'));
+
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,21 @@
+-- Filename: migrations/000020_create_notifications_table.up.sql
+-- The in-app inbox. title is rendered in the user's locale when the
+-- notification is created, and data holds the values the app links to
+CREATE TABLE IF NOT EXISTS notifications (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    category text NOT NULL,
+    kind text NOT NULL,
+    title text NOT NULL,
+    data jsonb NOT NULL DEFAULT '{}',
+    read_at timestamp(0) WITH TIME ZONE,
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_id_created_at_idx ON notifications (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+-- the inbox is a channel users can turn categories off on
+ALTER TABLE notification_preferences
+    DROP CONSTRAINT IF EXISTS notification_preferences_channel_check,
+    ADD CONSTRAINT notification_preferences_channel_check CHECK (channel IN ('email', 'in_app'));
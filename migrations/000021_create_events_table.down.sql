@@ -0,0 +1,6 @@
+-- Filename: migrations/000021_create_events_table.down.sql
+DROP TRIGGER IF EXISTS notifications_record_event ON notifications;
+DROP TRIGGER IF EXISTS goals_record_event ON goals;
+DROP TRIGGER IF EXISTS study_sessions_record_event ON study_sessions;
+DROP FUNCTION IF EXISTS record_event();
+DROP TABLE IF EXISTS events;
@@ -0,0 +1,58 @@
+-- Filename: migrations/000021_create_events_table.up.sql
+-- Changes to a user's sessions, goals and notifications, streamed to them
+-- over /v1/events. Rows are kept for a while so a client that reconnects
+-- with Last-Event-ID gets what it missed. user_id has no foreign key:
+-- deleting a user deletes their goals and sessions, which records events
+-- for a user that is already gone. Old rows are pruned instead
+CREATE TABLE IF NOT EXISTS events (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    type text NOT NULL,
+    data jsonb NOT NULL,
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS events_user_id_id_idx ON events (user_id, id);
+CREATE INDEX IF NOT EXISTS events_created_at_idx ON events (created_at);
+
+-- record_event is an AFTER row trigger. Its arguments are the event name
+-- and the primary key column, e.g. record_event('goal', 'goal_id') records
+-- goal.created, goal.updated and goal.deleted with {"id": <goal_id>}. Every
+-- API instance LISTENs on the events channel for "<user id>:<event id>"
+CREATE OR REPLACE FUNCTION record_event() RETURNS trigger AS $$
+DECLARE
+    row_data jsonb;
+    owner_id bigint;
+    event_id bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        row_data := to_jsonb(OLD);
+    ELSE
+        row_data := to_jsonb(NEW);
+    END IF;
+    owner_id := (row_data ->> 'user_id')::bigint;
+
+    INSERT INTO events (user_id, type, data)
+    VALUES (
+        owner_id,
+        TG_ARGV[0] || CASE TG_OP WHEN 'INSERT' THEN '.created' WHEN 'UPDATE' THEN '.updated' ELSE '.deleted' END,
+        jsonb_build_object('id', (row_data ->> TG_ARGV[1])::bigint)
+    )
+    RETURNING id INTO event_id;
+
+    PERFORM pg_notify('events', owner_id || ':' || event_id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER study_sessions_record_event
+    AFTER INSERT OR UPDATE OR DELETE ON study_sessions
+    FOR EACH ROW EXECUTE FUNCTION record_event('study_session', 'session_id');
+
+CREATE TRIGGER goals_record_event
+    AFTER INSERT OR UPDATE OR DELETE ON goals
+    FOR EACH ROW EXECUTE FUNCTION record_event('goal', 'goal_id');
+
+CREATE TRIGGER notifications_record_event
+    AFTER INSERT OR UPDATE OR DELETE ON notifications
+    FOR EACH ROW EXECUTE FUNCTION record_event('notification', 'id');
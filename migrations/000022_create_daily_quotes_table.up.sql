@@ -0,0 +1,11 @@
+-- Filename: migrations/000022_create_daily_quotes_table.up.sql
+-- The quote each user got on each of their calendar days, so every device
+-- shows the same one and recent quotes aren't repeated
+CREATE TABLE IF NOT EXISTS daily_quotes (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    day date NOT NULL,
+    quote_id bigint NOT NULL REFERENCES quotes ON DELETE CASCADE,
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS daily_quotes_quote_id_idx ON daily_quotes (quote_id);
@@ -0,0 +1,2 @@
+-- Filename: migrations/000023_create_quote_hides_table.down.sql
+DROP TABLE IF EXISTS quote_hides;
@@ -0,0 +1,9 @@
+-- Filename: migrations/000023_create_quote_hides_table.up.sql
+-- Quotes a user doesn't want to see again in random picks or as their
+-- quote of the day
+CREATE TABLE IF NOT EXISTS quote_hides (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    quote_id bigint NOT NULL REFERENCES quotes ON DELETE CASCADE,
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, quote_id)
+);
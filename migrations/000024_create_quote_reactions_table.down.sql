@@ -0,0 +1,6 @@
+-- Filename: migrations/000024_create_quote_reactions_table.down.sql
+DROP TRIGGER IF EXISTS quote_reactions_count_likes ON quote_reactions;
+DROP FUNCTION IF EXISTS count_quote_likes();
+DROP INDEX IF EXISTS quotes_likes_count_idx;
+ALTER TABLE quotes DROP COLUMN IF EXISTS likes_count;
+DROP TABLE IF EXISTS quote_reactions;
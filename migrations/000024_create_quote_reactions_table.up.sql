@@ -0,0 +1,31 @@
+-- Filename: migrations/000024_create_quote_reactions_table.up.sql
+-- Quotes users have favorited. kind leaves room for other reactions
+CREATE TABLE IF NOT EXISTS quote_reactions (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    quote_id bigint NOT NULL REFERENCES quotes ON DELETE CASCADE,
+    kind text NOT NULL CHECK (kind IN ('favorite')),
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, quote_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS quote_reactions_quote_id_idx ON quote_reactions (quote_id);
+
+-- The favorite count is kept on the quote so lists can show and sort by it
+-- without counting
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS likes_count integer NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS quotes_likes_count_idx ON quotes (likes_count);
+
+CREATE OR REPLACE FUNCTION count_quote_likes() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' AND NEW.kind = 'favorite' THEN
+        UPDATE quotes SET likes_count = likes_count + 1 WHERE quote_id = NEW.quote_id;
+    ELSIF TG_OP = 'DELETE' AND OLD.kind = 'favorite' THEN
+        UPDATE quotes SET likes_count = likes_count - 1 WHERE quote_id = OLD.quote_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER quote_reactions_count_likes
+    AFTER INSERT OR DELETE ON quote_reactions
+    FOR EACH ROW EXECUTE FUNCTION count_quote_likes();
@@ -0,0 +1,19 @@
+-- Filename: migrations/000025_add_quote_moderation.down.sql
+DELETE FROM notification_preferences
+WHERE category = 'moderation';
+
+ALTER TABLE notification_preferences
+    DROP CONSTRAINT IF EXISTS notification_preferences_category_check,
+    ADD CONSTRAINT notification_preferences_category_check CHECK (category IN ('reminders', 'digests'));
+
+DELETE FROM permissions
+WHERE code = 'quotes:moderate';
+
+DROP TABLE IF EXISTS quote_reports;
+
+DROP INDEX IF EXISTS quotes_status_idx;
+ALTER TABLE quotes
+    DROP COLUMN IF EXISTS moderated_at,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS moderation_reason,
+    DROP COLUMN IF EXISTS status;
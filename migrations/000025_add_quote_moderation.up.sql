@@ -0,0 +1,32 @@
+-- Filename: migrations/000025_add_quote_moderation.up.sql
+-- Quotes from users without quotes:moderate wait in the moderation queue
+-- until approved. Quotes that existed before moderation stay visible
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'approved' CHECK (status IN ('pending', 'approved', 'rejected')),
+    ADD COLUMN IF NOT EXISTS moderation_reason text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS moderated_by bigint REFERENCES users ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS moderated_at timestamp(0) WITH TIME ZONE;
+
+ALTER TABLE quotes ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE INDEX IF NOT EXISTS quotes_status_idx ON quotes (status);
+
+-- Reports from users. Enough unresolved reports send an approved quote back
+-- to the queue, and a moderator's decision resolves them
+CREATE TABLE IF NOT EXISTS quote_reports (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    quote_id bigint NOT NULL REFERENCES quotes ON DELETE CASCADE,
+    reason text NOT NULL DEFAULT '',
+    resolved boolean NOT NULL DEFAULT false,
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, quote_id)
+);
+
+CREATE INDEX IF NOT EXISTS quote_reports_unresolved_idx ON quote_reports (quote_id) WHERE NOT resolved;
+
+INSERT INTO permissions (code) VALUES ('quotes:moderate');
+
+-- authors are told about moderation decisions
+ALTER TABLE notification_preferences
+    DROP CONSTRAINT IF EXISTS notification_preferences_category_check,
+    ADD CONSTRAINT notification_preferences_category_check CHECK (category IN ('reminders', 'digests', 'moderation'));
@@ -0,0 +1,8 @@
+-- Filename: migrations/000026_add_quote_authors_and_tags.down.sql
+DROP TABLE IF EXISTS quote_tags;
+DROP TABLE IF EXISTS tags;
+
+DROP INDEX IF EXISTS quotes_author_idx;
+ALTER TABLE quotes
+    DROP COLUMN IF EXISTS source,
+    DROP COLUMN IF EXISTS author;
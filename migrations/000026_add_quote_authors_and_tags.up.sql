@@ -0,0 +1,21 @@
+-- Filename: migrations/000026_add_quote_authors_and_tags.up.sql
+-- Who said the quote and where, as opposed to who submitted it
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS author text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS source text NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS quotes_author_idx ON quotes (lower(author));
+
+-- Tags are shared between quotes and double as categories
+CREATE TABLE IF NOT EXISTS tags (
+    tag_id bigserial PRIMARY KEY,
+    name text NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS quote_tags (
+    quote_id bigint NOT NULL REFERENCES quotes ON DELETE CASCADE,
+    tag_id bigint NOT NULL REFERENCES tags ON DELETE CASCADE,
+    PRIMARY KEY (quote_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS quote_tags_tag_id_idx ON quote_tags (tag_id);
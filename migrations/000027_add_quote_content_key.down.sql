@@ -0,0 +1,4 @@
+-- Filename: migrations/000027_add_quote_content_key.down.sql
+DROP INDEX IF EXISTS quotes_content_key_idx;
+ALTER TABLE quotes DROP COLUMN IF EXISTS content_key;
+DROP FUNCTION IF EXISTS normalize_quote(text);
@@ -0,0 +1,10 @@
+-- Filename: migrations/000027_add_quote_content_key.up.sql
+-- Quotes that only differ in case, punctuation or spacing get the same
+-- content_key, so imports can skip near-duplicates
+CREATE OR REPLACE FUNCTION normalize_quote(content text) RETURNS text AS $$
+    SELECT btrim(regexp_replace(lower(content), '[^[:alnum:]]+', ' ', 'g'))
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS content_key text GENERATED ALWAYS AS (normalize_quote(content)) STORED;
+
+CREATE INDEX IF NOT EXISTS quotes_content_key_idx ON quotes (content_key);
@@ -0,0 +1,5 @@
+-- Filename: migrations/000028_add_quote_visibility_and_follows.down.sql
+DROP TABLE IF EXISTS follows;
+
+DROP INDEX IF EXISTS quotes_visibility_idx;
+ALTER TABLE quotes DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,19 @@
+-- Filename: migrations/000028_add_quote_visibility_and_follows.up.sql
+-- Who may see a quote besides its author. Private quotes are personal
+-- reminders, so they never go to the moderation queue
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS visibility text NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'followers', 'private'));
+
+CREATE INDEX IF NOT EXISTS quotes_visibility_idx ON quotes (visibility);
+
+-- A follower sees the followee's followers-only quotes. Follows only go
+-- one way, so the followee doesn't see the follower's
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    followee_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX IF NOT EXISTS follows_followee_id_idx ON follows (followee_id);
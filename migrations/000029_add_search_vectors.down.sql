@@ -0,0 +1,21 @@
+-- Filename: migrations/000029_add_search_vectors.down.sql
+DROP TRIGGER IF EXISTS users_locale_search_vectors ON users;
+DROP TRIGGER IF EXISTS quotes_search_vector_update ON quotes;
+DROP TRIGGER IF EXISTS goals_search_vector_update ON goals;
+DROP TRIGGER IF EXISTS study_sessions_search_vector_update ON study_sessions;
+
+DROP FUNCTION IF EXISTS users_refresh_search_vectors();
+DROP FUNCTION IF EXISTS quotes_search_vector();
+DROP FUNCTION IF EXISTS goals_search_vector();
+DROP FUNCTION IF EXISTS study_sessions_search_vector();
+
+DROP INDEX IF EXISTS quotes_search_vector_idx;
+DROP INDEX IF EXISTS goals_search_vector_idx;
+DROP INDEX IF EXISTS study_sessions_search_vector_idx;
+
+ALTER TABLE quotes DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE goals DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE study_sessions DROP COLUMN IF EXISTS search_vector;
+
+DROP FUNCTION IF EXISTS owner_search_config(bigint);
+DROP FUNCTION IF EXISTS search_config(text);
@@ -0,0 +1,102 @@
+-- Filename: migrations/000029_add_search_vectors.up.sql
+-- Sessions, goals and quotes get a weighted search_vector kept up to date
+-- by triggers. Words are stemmed for the owner's locale, so "studying"
+-- finds "study" in English and "estudiando" finds "estudiar" in Spanish
+CREATE OR REPLACE FUNCTION search_config(locale text) RETURNS regconfig AS $$
+    SELECT CASE split_part(locale, '-', 1)
+        WHEN 'en' THEN 'english'::regconfig
+        WHEN 'es' THEN 'spanish'::regconfig
+        ELSE 'simple'::regconfig
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION owner_search_config(owner_id bigint) RETURNS regconfig AS $$
+    SELECT COALESCE((SELECT search_config(locale) FROM users WHERE id = owner_id), 'simple'::regconfig)
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE study_sessions ADD COLUMN IF NOT EXISTS search_vector tsvector;
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS search_vector tsvector;
+ALTER TABLE quotes ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- A title or quote counts most, then the subject or author, then the rest
+CREATE OR REPLACE FUNCTION study_sessions_search_vector() RETURNS trigger AS $$
+DECLARE
+    config regconfig := owner_search_config(NEW.user_id);
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector(config, COALESCE(NEW.title, '')), 'A') ||
+        setweight(to_tsvector(config, COALESCE(NEW.subject, '')), 'B') ||
+        setweight(to_tsvector(config, COALESCE(NEW.description, '')), 'C');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION goals_search_vector() RETURNS trigger AS $$
+DECLARE
+    config regconfig := owner_search_config(NEW.user_id);
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector(config, COALESCE(NEW.goal_text, '')), 'A') ||
+        setweight(to_tsvector(config, COALESCE(NEW.subject, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION quotes_search_vector() RETURNS trigger AS $$
+DECLARE
+    config regconfig := owner_search_config(NEW.user_id);
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector(config, COALESCE(NEW.content, '')), 'A') ||
+        setweight(to_tsvector(config, COALESCE(NEW.author, '')), 'B') ||
+        setweight(to_tsvector(config, COALESCE(NEW.source, '')), 'C');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS study_sessions_search_vector_update ON study_sessions;
+CREATE TRIGGER study_sessions_search_vector_update
+    BEFORE INSERT OR UPDATE OF user_id, title, subject, description ON study_sessions
+    FOR EACH ROW EXECUTE FUNCTION study_sessions_search_vector();
+
+DROP TRIGGER IF EXISTS goals_search_vector_update ON goals;
+CREATE TRIGGER goals_search_vector_update
+    BEFORE INSERT OR UPDATE OF user_id, goal_text, subject ON goals
+    FOR EACH ROW EXECUTE FUNCTION goals_search_vector();
+
+DROP TRIGGER IF EXISTS quotes_search_vector_update ON quotes;
+CREATE TRIGGER quotes_search_vector_update
+    BEFORE INSERT OR UPDATE OF user_id, content, author, source ON quotes
+    FOR EACH ROW EXECUTE FUNCTION quotes_search_vector();
+
+-- Changing locale re-stems everything the user owns
+CREATE OR REPLACE FUNCTION users_refresh_search_vectors() RETURNS trigger AS $$
+BEGIN
+    UPDATE study_sessions SET title = title WHERE user_id = NEW.id;
+    UPDATE goals SET goal_text = goal_text WHERE user_id = NEW.id;
+    UPDATE quotes SET content = content WHERE user_id = NEW.id;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS users_locale_search_vectors ON users;
+CREATE TRIGGER users_locale_search_vectors
+    AFTER UPDATE OF locale ON users
+    FOR EACH ROW WHEN (OLD.locale IS DISTINCT FROM NEW.locale)
+    EXECUTE FUNCTION users_refresh_search_vectors();
+
+-- Fill in the existing rows through the triggers. Nothing the owners see
+-- changes, so no events are recorded for it
+ALTER TABLE study_sessions DISABLE TRIGGER study_sessions_record_event;
+ALTER TABLE goals DISABLE TRIGGER goals_record_event;
+
+UPDATE study_sessions SET title = title;
+UPDATE goals SET goal_text = goal_text;
+UPDATE quotes SET content = content;
+
+ALTER TABLE study_sessions ENABLE TRIGGER study_sessions_record_event;
+ALTER TABLE goals ENABLE TRIGGER goals_record_event;
+
+CREATE INDEX IF NOT EXISTS study_sessions_search_vector_idx ON study_sessions USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS goals_search_vector_idx ON goals USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS quotes_search_vector_idx ON quotes USING GIN (search_vector);
@@ -0,0 +1,8 @@
+-- Filename: migrations/000030_add_trigram_search.down.sql
+DROP INDEX IF EXISTS users_username_trgm_idx;
+DROP INDEX IF EXISTS quotes_content_trgm_idx;
+DROP INDEX IF EXISTS goals_goal_text_trgm_idx;
+DROP INDEX IF EXISTS study_sessions_subject_trgm_idx;
+DROP INDEX IF EXISTS study_sessions_title_trgm_idx;
+
+DROP EXTENSION IF EXISTS pg_trgm;
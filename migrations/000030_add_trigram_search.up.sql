@@ -0,0 +1,10 @@
+-- Filename: migrations/000030_add_trigram_search.up.sql
+-- Trigram indexes let searches match partial words and typos, so "calc"
+-- and "calculs" both find "Calculus"
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS study_sessions_title_trgm_idx ON study_sessions USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS study_sessions_subject_trgm_idx ON study_sessions USING GIN (subject gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS goals_goal_text_trgm_idx ON goals USING GIN (goal_text gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS quotes_content_trgm_idx ON quotes USING GIN (content gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS users_username_trgm_idx ON users USING GIN (username gin_trgm_ops);
@@ -0,0 +1,2 @@
+-- Filename: migrations/000031_add_goal_reopened.down.sql
+ALTER TABLE goals DROP COLUMN IF EXISTS reopened;
@@ -0,0 +1,4 @@
+-- Filename: migrations/000031_add_goal_reopened.up.sql
+-- A goal the user marked as not completed again stays open, even when its
+-- progress still reaches the target
+ALTER TABLE goals ADD COLUMN IF NOT EXISTS reopened boolean NOT NULL DEFAULT false;
@@ -0,0 +1,7 @@
+-- Filename: migrations/000032_add_follow_notifications.down.sql
+DELETE FROM notification_preferences
+WHERE category = 'follows';
+
+ALTER TABLE notification_preferences
+    DROP CONSTRAINT IF EXISTS notification_preferences_category_check,
+    ADD CONSTRAINT notification_preferences_category_check CHECK (category IN ('reminders', 'digests', 'moderation'));
@@ -0,0 +1,5 @@
+-- Filename: migrations/000032_add_follow_notifications.up.sql
+-- users are told when someone new follows them
+ALTER TABLE notification_preferences
+    DROP CONSTRAINT IF EXISTS notification_preferences_category_check,
+    ADD CONSTRAINT notification_preferences_category_check CHECK (category IN ('reminders', 'digests', 'moderation', 'follows'));
@@ -0,0 +1,23 @@
+-- Filename: migrations/000033_index_quotes_without_stemming.down.sql
+CREATE OR REPLACE FUNCTION quotes_search_vector() RETURNS trigger AS $$
+DECLARE
+    config regconfig := owner_search_config(NEW.user_id);
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector(config, COALESCE(NEW.content, '')), 'A') ||
+        setweight(to_tsvector(config, COALESCE(NEW.author, '')), 'B') ||
+        setweight(to_tsvector(config, COALESCE(NEW.source, '')), 'C');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION users_refresh_search_vectors() RETURNS trigger AS $$
+BEGIN
+    UPDATE study_sessions SET title = title WHERE user_id = NEW.id;
+    UPDATE goals SET goal_text = goal_text WHERE user_id = NEW.id;
+    UPDATE quotes SET content = content WHERE user_id = NEW.id;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+UPDATE quotes SET content = content;
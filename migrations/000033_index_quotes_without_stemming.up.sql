@@ -0,0 +1,24 @@
+-- Filename: migrations/000033_index_quotes_without_stemming.up.sql
+-- Quotes are searched by everyone, whatever their locale, so they are
+-- indexed with the simple config rather than stemmed for their owner's
+-- locale, and searched with the same config
+CREATE OR REPLACE FUNCTION quotes_search_vector() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', COALESCE(NEW.content, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(NEW.author, '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(NEW.source, '')), 'C');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+-- Changing locale no longer affects the owner's quotes
+CREATE OR REPLACE FUNCTION users_refresh_search_vectors() RETURNS trigger AS $$
+BEGIN
+    UPDATE study_sessions SET title = title WHERE user_id = NEW.id;
+    UPDATE goals SET goal_text = goal_text WHERE user_id = NEW.id;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+UPDATE quotes SET content = content;
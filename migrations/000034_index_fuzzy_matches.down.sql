@@ -0,0 +1,6 @@
+-- Filename: migrations/000034_index_fuzzy_matches.down.sql
+DROP INDEX IF EXISTS users_username_words_idx;
+DROP INDEX IF EXISTS quotes_content_words_idx;
+DROP INDEX IF EXISTS goals_goal_text_words_idx;
+DROP INDEX IF EXISTS study_sessions_subject_words_idx;
+DROP INDEX IF EXISTS study_sessions_title_words_idx;
@@ -0,0 +1,9 @@
+-- Filename: migrations/000034_index_fuzzy_matches.up.sql
+-- Searches also match the words in any order. Similar words are found with
+-- the trigram indexes, going by pg_trgm.word_similarity_threshold, which
+-- the API sets for each search
+CREATE INDEX IF NOT EXISTS study_sessions_title_words_idx ON study_sessions USING GIN (to_tsvector('simple', title));
+CREATE INDEX IF NOT EXISTS study_sessions_subject_words_idx ON study_sessions USING GIN (to_tsvector('simple', subject));
+CREATE INDEX IF NOT EXISTS goals_goal_text_words_idx ON goals USING GIN (to_tsvector('simple', goal_text));
+CREATE INDEX IF NOT EXISTS quotes_content_words_idx ON quotes USING GIN (to_tsvector('simple', content));
+CREATE INDEX IF NOT EXISTS users_username_words_idx ON users USING GIN (to_tsvector('simple', username));
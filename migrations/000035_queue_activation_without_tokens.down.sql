@@ -0,0 +1,14 @@
+-- Filename: migrations/000035_queue_activation_without_tokens.down.sql
+-- The tokens can't be brought back, so the welcome emails that haven't
+-- gone out are dropped
+DELETE FROM jobs
+WHERE kind = 'send_activation_email' AND status IN ('pending', 'running');
+
+UPDATE jobs
+SET kind = 'send_email',
+    payload = jsonb_build_object(
+        'recipient', '',
+        'template', 'user_welcome.tmpl',
+        'data', jsonb_build_object('userID', payload->'user_id')
+    )
+WHERE kind = 'send_activation_email';
@@ -0,0 +1,12 @@
+-- Filename: migrations/000035_queue_activation_without_tokens.up.sql
+-- Welcome emails used to be queued with the activation token in the
+-- payload, where anyone with jobs:read could see it. They become jobs that
+-- create the token when they run. Finished ones keep their status, so the
+-- only thing that changes for them is that the token is gone
+UPDATE jobs
+SET kind = 'send_activation_email',
+    payload = jsonb_strip_nulls(jsonb_build_object(
+        'user_id', (payload->'data'->>'userID')::bigint,
+        'locale', payload->>'locale'
+    ))
+WHERE kind = 'send_email' AND payload->>'template' = 'user_welcome.tmpl';
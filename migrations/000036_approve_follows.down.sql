@@ -0,0 +1,2 @@
+-- Filename: migrations/000036_approve_follows.down.sql
+ALTER TABLE follows DROP COLUMN IF EXISTS approved;
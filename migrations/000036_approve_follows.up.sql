@@ -0,0 +1,6 @@
+-- Filename: migrations/000036_approve_follows.up.sql
+-- A follow is a request until the followee approves it, and only approved
+-- followers see followers-only quotes. Follows made before this needed no
+-- approval, so they become requests too
+ALTER TABLE follows
+    ADD COLUMN IF NOT EXISTS approved boolean NOT NULL DEFAULT false;
@@ -0,0 +1,26 @@
+-- Filename: migrations/000037_skip_search_vector_events.down.sql
+CREATE OR REPLACE FUNCTION record_event() RETURNS trigger AS $$
+DECLARE
+    row_data jsonb;
+    owner_id bigint;
+    event_id bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        row_data := to_jsonb(OLD);
+    ELSE
+        row_data := to_jsonb(NEW);
+    END IF;
+    owner_id := (row_data ->> 'user_id')::bigint;
+
+    INSERT INTO events (user_id, type, data)
+    VALUES (
+        owner_id,
+        TG_ARGV[0] || CASE TG_OP WHEN 'INSERT' THEN '.created' WHEN 'UPDATE' THEN '.updated' ELSE '.deleted' END,
+        jsonb_build_object('id', (row_data ->> TG_ARGV[1])::bigint)
+    )
+    RETURNING id INTO event_id;
+
+    PERFORM pg_notify('events', owner_id || ':' || event_id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;